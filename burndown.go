@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/csv"
+	"math"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	BurndownPerDay  = "Per Day"
+	BurndownPerWeek = "Per Week"
+)
+
+// BurndownMaxPeriods is the most periods (days or weeks) a Burndown goes back.
+const BurndownMaxPeriods = 30
+
+// BurndownVelocityPeriods is how many of the most recent periods velocity is measured over.
+const BurndownVelocityPeriods = 4
+
+// BurndownPoint is the state of a set of tasks at the end of a period.
+type BurndownPoint struct {
+	Start     time.Time
+	Remaining int // Tasks left to do at the end of the period
+	Completed int // Tasks completed during the period
+}
+
+// Burndown is how a set of tasks (Cards that can be completed) has been worked through over time, measured using the Cards'
+// created and completed timestamps.
+type Burndown struct {
+	Period    string
+	Points    []BurndownPoint
+	Velocity  float32   // Tasks completed per period, on average, recently
+	Forecast  time.Time // When the remaining tasks should be done at the current velocity; zero if there's no velocity
+	Remaining int
+}
+
+func burndownPeriodStart(t time.Time, period string) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	if period == BurndownPerWeek {
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	}
+	return day
+}
+
+func burndownNextPeriod(t time.Time, period string) time.Time {
+	if period == BurndownPerWeek {
+		return t.AddDate(0, 0, 7)
+	}
+	return t.AddDate(0, 0, 1)
+}
+
+// NewBurndown measures a Burndown for the given Cards, up to now. Cards without a created time are counted as having always
+// existed.
+func NewBurndown(cards []*Card, period string, now time.Time) *Burndown {
+
+	burndown := &Burndown{Period: period}
+
+	tasks := []*Card{}
+	earliest := now
+
+	for _, card := range cards {
+
+		if !card.Valid || !card.Numberable() {
+			continue
+		}
+
+		tasks = append(tasks, card)
+
+		for _, name := range []string{TimestampCreated, TimestampCompleted} {
+			if t := card.Timestamp(name); !t.IsZero() && t.Before(earliest) {
+				earliest = t
+			}
+		}
+
+	}
+
+	last := burndownPeriodStart(now, period)
+	start := burndownPeriodStart(earliest, period)
+
+	// Only go back so far.
+	first := last
+	for i := 1; i < BurndownMaxPeriods && first.After(start); i++ {
+		if period == BurndownPerWeek {
+			first = first.AddDate(0, 0, -7)
+		} else {
+			first = first.AddDate(0, 0, -1)
+		}
+	}
+
+	for periodStart := first; !periodStart.After(last); periodStart = burndownNextPeriod(periodStart, period) {
+
+		periodEnd := burndownNextPeriod(periodStart, period)
+
+		point := BurndownPoint{Start: periodStart}
+
+		for _, card := range tasks {
+
+			created := card.Timestamp(TimestampCreated)
+			completed := card.Timestamp(TimestampCompleted)
+
+			// Skip Cards that didn't exist yet, and Cards that were completed before completion times were recorded.
+			if (!created.IsZero() && !created.Before(periodEnd)) || (completed.IsZero() && card.Completed()) {
+				continue
+			}
+
+			if completed.IsZero() || !completed.Before(periodEnd) {
+				point.Remaining++
+			} else if !completed.Before(periodStart) {
+				point.Completed++
+			}
+
+		}
+
+		burndown.Points = append(burndown.Points, point)
+
+	}
+
+	burndown.Remaining = burndown.Points[len(burndown.Points)-1].Remaining
+
+	recent := burndown.Points
+	if len(recent) > BurndownVelocityPeriods {
+		recent = recent[len(recent)-BurndownVelocityPeriods:]
+	}
+
+	completed := 0
+	for _, point := range recent {
+		completed += point.Completed
+	}
+
+	burndown.Velocity = float32(completed) / float32(len(recent))
+
+	if burndown.Velocity > 0 {
+		periods := int(math.Ceil(float64(float32(burndown.Remaining) / burndown.Velocity)))
+		burndown.Forecast = last
+		for i := 0; i < periods; i++ {
+			burndown.Forecast = burndownNextPeriod(burndown.Forecast, period)
+		}
+	}
+
+	return burndown
+
+}
+
+// ForecastValues returns the remaining tasks projected forward from the last period at the measured velocity, until they
+// run out (or until maxPeriods have passed).
+func (burndown *Burndown) ForecastValues(maxPeriods int) []float32 {
+
+	values := []float32{float32(burndown.Remaining)}
+
+	if burndown.Velocity <= 0 {
+		return values
+	}
+
+	for remaining := float32(burndown.Remaining); remaining > 0 && len(values) <= maxPeriods; {
+		remaining -= burndown.Velocity
+		if remaining < 0 {
+			remaining = 0
+		}
+		values = append(values, remaining)
+	}
+
+	return values
+
+}
+
+// ExportCSV writes the Burndown's data points to a CSV file.
+func (burndown *Burndown) ExportCSV(filename string) error {
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+
+	writer.Write([]string{"period start", "remaining", "completed"})
+
+	for _, point := range burndown.Points {
+		writer.Write([]string{point.Start.Format("2006-01-02"), strconv.Itoa(point.Remaining), strconv.Itoa(point.Completed)})
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+
+}
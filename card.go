@@ -5,6 +5,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
@@ -80,6 +81,8 @@ func (le *LinkEnding) Update() {
 
 				if ClickedInRect(r, true) {
 
+					le.Start.Page.Project.UndoHistory.Track(le)
+
 					if globals.Mouse.Button(sdl.BUTTON_LEFT).PressedTimes(2) {
 						removeJoint = i
 					} else {
@@ -99,7 +102,7 @@ func (le *LinkEnding) Update() {
 					joint.Position.X = float32(math.Round(float64(joint.Position.X/globals.GridSize)) * float64(globals.GridSize))
 					joint.Position.Y = float32(math.Round(float64(joint.Position.Y/globals.GridSize)) * float64(globals.GridSize))
 					joint.Dragging = false
-					le.Start.CreateUndoState = true
+					le.captureUndo()
 				}
 
 			}
@@ -108,7 +111,7 @@ func (le *LinkEnding) Update() {
 
 		if removeJoint >= 0 {
 			le.Joints = append(le.Joints[:removeJoint], le.Joints[removeJoint+1:]...)
-			le.Start.CreateUndoState = true
+			le.captureUndo()
 		}
 
 	}
@@ -142,6 +145,8 @@ func (le *LinkEnding) Update() {
 
 		if ClickedInRect(r, true) {
 
+			le.Start.Page.Project.UndoHistory.Track(le)
+
 			lj := NewLinkJoint(center.X, center.Y)
 			lj.Dragging = true
 
@@ -158,6 +163,54 @@ func (le *LinkEnding) Update() {
 
 }
 
+// captureUndo captures the LinkEnding's joints after they've been changed. The Card the link starts from serializes the joints
+// as well, so it's captured too; otherwise undoing a later change to the Card would put the joints back where they were.
+func (le *LinkEnding) captureUndo() {
+	le.Start.Page.Project.UndoHistory.Capture(NewUndoState(le))
+	le.Start.CreateUndoState = true
+}
+
+// live returns the LinkEnding currently joining the Cards; it may not be this one, as Cards recreate their links when they're
+// deserialized.
+func (le *LinkEnding) live() *LinkEnding {
+	for _, link := range le.Start.Links {
+		if link.Start == le.Start && link.End == le.End {
+			return link
+		}
+	}
+	return nil
+}
+
+func (le *LinkEnding) UndoData() string {
+	jointPos := []Point{}
+	for _, joint := range le.Joints {
+		jointPos = append(jointPos, joint.Position)
+	}
+	data, _ := sjson.Set("{}", "joints", jointPos)
+	return data
+}
+
+func (le *LinkEnding) ApplyUndoData(data string, deletion bool) {
+
+	link := le.live()
+	if link == nil {
+		return
+	}
+
+	link.Joints = []*LinkJoint{}
+	for _, joint := range gjson.Get(data, "joints").Array() {
+		link.Joints = append(link.Joints, NewLinkJoint(float32(joint.Get("X").Float()), float32(joint.Get("Y").Float())))
+	}
+
+}
+
+// Whether a link exists is part of its Cards' states, so there's nothing to do for the link itself.
+func (le *LinkEnding) UndoUncreate() {}
+
+func (le *LinkEnding) UndoPage() *Page {
+	return le.Start.Page
+}
+
 func (le *LinkEnding) Draw() {
 
 	if le.Start != nil && le.Start.Valid && le.Start.Contents != nil {
@@ -307,12 +360,26 @@ type Card struct {
 
 	Collapsed       string
 	UncollapsedSize Point
+	Hidden          bool // Whether the Card is hidden within a collapsed Frame
 
 	Highlighter     *Highlighter
 	DrawHighlighter bool
 
 	Links              []*LinkEnding
 	LinkRectPercentage float32
+
+	wasCompleted      bool
+	keepCompletedTime bool
+
+	// Values parsed from the Card's Properties that are drawn every frame; they're cleared whenever the Properties change.
+	tags             []string
+	tagsCached       bool
+	timeSpent        time.Duration
+	timeSpentCached  bool
+	recurrence       *Recurrence
+	recurrenceCached bool
+	pastStreak       int
+	pastStreakCached bool
 }
 
 var globalCardID = int64(0)
@@ -340,7 +407,10 @@ func NewCard(page *Page, contentType string) *Card {
 	card.Page.AddDrawable(card.Drawable)
 
 	card.Properties = NewProperties()
-	card.Properties.OnChange = func(property *Property) { card.CreateUndoState = true }
+	card.Properties.OnChange = func(property *Property) {
+		card.CreateUndoState = true
+		card.clearPropertyCache()
+	}
 
 	globalCardID++
 
@@ -459,7 +529,14 @@ func (card *Card) Update() {
 		card.Contents.Update()
 	}
 
-	if card.Page.IsCurrent() {
+	card.updateCompletedTime()
+
+	// Periods only end at midnight, so there's no need to check constantly.
+	if globals.Frame%30 == 0 {
+		card.updateRecurrence()
+	}
+
+	if card.Page.IsCurrent() && !card.Hidden {
 
 		if card.selected && globals.Keybindings.Pressed(KBUnlinkCard) && globals.State == StateNeutral {
 			card.UnlinkAll()
@@ -685,6 +762,10 @@ func (card *Card) UnlinkAll() {
 
 func (card *Card) DrawShadow() {
 
+	if card.Hidden {
+		return
+	}
+
 	tp := card.Page.Project.Camera.TranslateRect(card.DisplayRect)
 
 	tp.X += 8
@@ -737,6 +818,10 @@ func (card *Card) NearestPointInRect(in Point) Point {
 
 func (card *Card) DrawCard() {
 
+	if card.Hidden {
+		return
+	}
+
 	for _, link := range card.Links {
 		if link.Start == card && link.End.Valid {
 			link.Draw()
@@ -765,6 +850,13 @@ func (card *Card) DrawCard() {
 
 	card.DrawContents()
 
+	// Dim Cards that don't pass the Project's tag filter.
+	if !card.Page.Project.PassesTagFilter(card) {
+		dimColor := getThemeColor(GUIBGColor)
+		dimColor[3] = 192
+		FillRect(tp.X, tp.Y, tp.W, tp.H, dimColor)
+	}
+
 }
 
 func (card *Card) Color() Color {
@@ -789,7 +881,7 @@ func (card *Card) DrawContents() {
 
 	if card.CreateUndoState {
 
-		card.Page.Project.UndoHistory.Capture(NewUndoState(card))
+		card.captureChange()
 
 		card.CreateUndoState = false
 
@@ -799,6 +891,10 @@ func (card *Card) DrawContents() {
 
 func (card *Card) PostDraw() {
 
+	if card.Hidden {
+		return
+	}
+
 	if card.Page.Linking == card {
 
 		translatedStart := card.Page.Project.Camera.TranslatePoint(Point{card.DisplayRect.X + (card.DisplayRect.W / 2), card.DisplayRect.Y + (card.DisplayRect.H / 2)})
@@ -867,6 +963,132 @@ func (card *Card) PostDraw() {
 
 	}
 
+	card.DrawTags()
+
+}
+
+// DrawTags draws the Card's tags as small colored pills running along the right side of the Card's top edge.
+func (card *Card) DrawTags() {
+
+	tags := card.Tags()
+
+	if len(tags) == 0 {
+		return
+	}
+
+	x := card.DisplayRect.X + card.DisplayRect.W - 8
+	y := card.DisplayRect.Y - 8
+
+	for i := len(tags) - 1; i >= 0; i-- {
+
+		tag := tags[i]
+		textSize := globals.TextRenderer.MeasureText([]rune(tag), 0.5)
+		w := textSize.X + 8
+		x -= w
+
+		pos := card.Page.Project.Camera.TranslatePoint(Point{x, y})
+		color := card.Page.Project.TagColor(tag)
+
+		FillRect(pos.X, pos.Y, w, textSize.Y, color)
+		globals.TextRenderer.QuickRenderText(tag, Point{pos.X + (w / 2), pos.Y}, 0.5, color.Invert(), AlignCenter)
+
+		x -= 4
+
+	}
+
+}
+
+// Tags returns the tags assigned to the Card, in the order they were entered.
+// Tags returns the Card's tags. They're cached, as they're drawn and checked against the tag filter every frame; the returned
+// slice shouldn't be modified.
+func (card *Card) Tags() []string {
+
+	if !card.tagsCached {
+
+		card.tags = []string{}
+
+		if card.Properties.Has("tags") {
+			card.tags = ParseTags(card.Properties.Get("tags").AsString())
+		}
+
+		card.tagsCached = true
+
+	}
+
+	return card.tags
+
+}
+
+func (card *Card) HasTag(tag string) bool {
+	for _, t := range card.Tags() {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// SetTags sets the Card's tags, adding any new ones to the Project's tag palette.
+func (card *Card) SetTags(tags ...string) {
+
+	tags = ParseTags(strings.Join(tags, ","))
+
+	if len(tags) == 0 && !card.Properties.Has("tags") {
+		return
+	}
+
+	for _, tag := range tags {
+		card.Page.Project.TagColor(tag)
+	}
+
+	card.Properties.Get("tags").Set(strings.Join(tags, ", "))
+
+}
+
+// OwnsProperty returns if the named Property belongs to the Card itself rather than its Contents, and so should be kept when switching
+// content types. Data tracked about a Card (its tags, timestamps, reminder, logged time, and so on) is kept in its Properties rather
+// than in fields, so that it's saved, copied, and undone along with the rest of the Card.
+func (card *Card) OwnsProperty(name string) bool {
+	switch name {
+	case "tags", TimestampCreated, TimestampModified, TimestampCompleted, ReminderProperty, ReminderFiredProperty:
+		return true
+	case RecurrenceProperty, RecurrencePeriodProperty, RecurrenceHistoryProperty:
+		return true
+	case TimeSessionsProperty:
+		return true
+	}
+	return false
+}
+
+// ParseTags splits a comma-separated list of tags, trimming whitespace and discarding empty and duplicate entries.
+func ParseTags(text string) []string {
+
+	tags := []string{}
+
+	for _, tag := range strings.Split(text, ",") {
+
+		tag = strings.TrimSpace(tag)
+
+		if tag == "" {
+			continue
+		}
+
+		exists := false
+		for _, t := range tags {
+			if t == tag {
+				exists = true
+				break
+			}
+		}
+
+		if !exists {
+			tags = append(tags, tag)
+		}
+
+	}
+
+	return tags
+
 }
 
 func (card *Card) Numberable() bool {
@@ -906,6 +1128,10 @@ func (card *Card) Serialize() string {
 	if card.CustomColor != nil {
 		data, _ = sjson.Set(data, "custom color", card.CustomColor.ToHexString())
 	}
+	if card.Collapsed != CollapsedNone {
+		data, _ = sjson.Set(data, "collapsed", card.Collapsed)
+		data, _ = sjson.Set(data, "uncollapsed size", card.UncollapsedSize)
+	}
 	data, _ = sjson.SetRaw(data, "properties", card.Properties.Serialize())
 
 	if len(card.Links) > 0 {
@@ -944,8 +1170,19 @@ func (card *Card) Serialize() string {
 
 }
 
+// clearPropertyCache clears the values parsed from the Card's Properties, so they're parsed again the next time they're needed.
+// Properties set with SetRaw() or removed don't fire OnChange, so anything doing that to a cached Property should call this.
+func (card *Card) clearPropertyCache() {
+	card.tagsCached = false
+	card.timeSpentCached = false
+	card.recurrenceCached = false
+	card.pastStreakCached = false
+}
+
 func (card *Card) Deserialize(data string) {
 
+	card.keepCompletedTime = true
+
 	for _, link := range append([]*LinkEnding{}, card.Links...) {
 		if link.Start == card {
 			card.Unlink(link.End)
@@ -958,6 +1195,11 @@ func (card *Card) Deserialize(data string) {
 
 	if card.Page.Project.Loading && gjson.Get(data, "id").Exists() {
 		card.LoadedID = gjson.Get(data, "id").Int()
+		// Keep the saved ID, so the Card can be matched up with the project file again later.
+		card.ID = card.LoadedID
+		if globalCardID <= card.ID {
+			globalCardID = card.ID + 1
+		}
 	}
 
 	linkedTo := []int64{}
@@ -982,6 +1224,16 @@ func (card *Card) Deserialize(data string) {
 		card.CustomColor = nil
 	}
 
+	if gjson.Get(data, "collapsed").Exists() {
+		card.Collapsed = gjson.Get(data, "collapsed").String()
+		uncollapsedSize := gjson.Get(data, "uncollapsed size")
+		card.UncollapsedSize = Point{float32(uncollapsedSize.Get("X").Float()), float32(uncollapsedSize.Get("Y").Float())}
+	} else {
+		card.Collapsed = CollapsedNone
+	}
+
+	card.Page.UpdateHidden = true
+
 	// for _, link := range card.Links {
 	// 	found := false
 	// 	// for _, gl := range linkedTo {
@@ -1000,6 +1252,7 @@ func (card *Card) Deserialize(data string) {
 	card.Recreate(float32(rect.Get("W").Float()), float32(rect.Get("H").Float()))
 
 	card.Properties.Deserialize(gjson.Get(data, "properties").Raw)
+	card.clearPropertyCache()
 
 	// card.ReceiveMessage(NewMessage(MessageCardDeserialized, nil, nil))
 
@@ -1015,6 +1268,34 @@ func (card *Card) Deserialize(data string) {
 
 }
 
+func (card *Card) UndoData() string {
+	return card.Serialize()
+}
+
+func (card *Card) ApplyUndoData(data string, deletion bool) {
+
+	card.Deserialize(data)
+	card.ReceiveMessage(NewMessage(MessageUndoRedo, card, nil))
+	card.CreateUndoState = false
+
+	if deletion {
+		card.Page.DeleteCards(card)
+	} else if !card.Valid {
+		card.Page.RestoreCards(card)
+	}
+
+}
+
+func (card *Card) UndoUncreate() {
+	if card.Valid {
+		card.Page.DeleteCards(card)
+	}
+}
+
+func (card *Card) UndoPage() *Page {
+	return card.Page
+}
+
 func (card *Card) Select() {
 	if !card.selected {
 		card.Page.Raise(card)
@@ -1067,6 +1348,7 @@ func (card *Card) StopResizing() {
 		card.UncollapsedSize = Point{card.Rect.W, card.UncollapsedSize.Y}
 	}
 
+	card.Page.UpdateHidden = true
 	card.CreateUndoState = true
 
 }
@@ -1243,6 +1525,8 @@ func (card *Card) ReceiveMessage(message *Message) {
 		card.Contents.ReceiveMessage(message)
 	}
 
+	card.Page.Project.Scripts.ReceiveMessage(card, message)
+
 	if message.Type == MessageCardDeleted {
 		card.Page.RemoveDrawable(card.Drawable)
 		card.Page.Grid.Remove(card)
@@ -1280,7 +1564,9 @@ func (card *Card) SetContents(contentType string) {
 	} else {
 
 		for _, prop := range card.Properties.Props {
-			prop.InUse = false
+			if !card.OwnsProperty(prop.Name) {
+				prop.InUse = false
+			}
 		}
 
 		switch contentType {
@@ -1300,6 +1586,8 @@ func (card *Card) SetContents(contentType string) {
 			card.Contents = NewMapContents(card)
 		case ContentTypeSubpage:
 			card.Contents = NewSubPageContents(card)
+		case ContentTypeFrame:
+			card.Contents = NewFrameContents(card)
 		default:
 			panic("Creation of card contents that haven't been implemented: " + contentType)
 		}
@@ -1342,6 +1630,8 @@ func (card *Card) Collapse() {
 		card.Collapsed = CollapsedNone
 	}
 
+	card.Page.UpdateHidden = true
+
 	if card.Collapsed == CollapsedNone {
 		card.Recreate(card.UncollapsedSize.X, card.UncollapsedSize.Y)
 	} else {
@@ -0,0 +1,427 @@
+package main
+
+import (
+	"sort"
+	"time"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// A Checkpoint is a named snapshot of a Project (i.e. "End of sprint 3"), saved inside the project file. Unlike the undo
+// history, checkpoints are only made when asked for, and they're kept until they're deleted.
+type Checkpoint struct {
+	Name  string
+	Time  time.Time
+	Pages string // The Project's pages, serialized as they are in the project file
+
+	view *Project
+}
+
+// CreateCheckpoint records the current state of the Project as a new Checkpoint.
+func (project *Project) CreateCheckpoint(name string) *Checkpoint {
+
+	checkpoint := &Checkpoint{
+		Name:  name,
+		Time:  time.Now(),
+		Pages: project.serializePages(),
+	}
+
+	project.Checkpoints = append(project.Checkpoints, checkpoint)
+	project.Modified = true
+
+	globals.EventLog.Log("Checkpoint '%s' created.", name)
+
+	return checkpoint
+
+}
+
+func (project *Project) DeleteCheckpoint(checkpoint *Checkpoint) {
+
+	for i, c := range project.Checkpoints {
+		if c == checkpoint {
+			project.Checkpoints = append(project.Checkpoints[:i], project.Checkpoints[i+1:]...)
+			break
+		}
+	}
+
+	project.CloseCheckpointTab(checkpoint)
+
+	project.Modified = true
+
+	globals.EventLog.Log("Checkpoint '%s' deleted.", checkpoint.Name)
+
+}
+
+func (project *Project) serializeCheckpoints() string {
+
+	data := "[]"
+
+	for _, checkpoint := range project.Checkpoints {
+		checkpointData, _ := sjson.Set("{}", "name", checkpoint.Name)
+		checkpointData, _ = sjson.Set(checkpointData, "time", checkpoint.Time.Unix())
+		checkpointData, _ = sjson.SetRaw(checkpointData, "pages", checkpoint.Pages)
+		data, _ = sjson.SetRaw(data, "-1", checkpointData)
+	}
+
+	return data
+
+}
+
+func (project *Project) deserializeCheckpoints(checkpoints []gjson.Result) {
+
+	for _, checkpointData := range checkpoints {
+		project.Checkpoints = append(project.Checkpoints, &Checkpoint{
+			Name:  checkpointData.Get("name").String(),
+			Time:  time.Unix(checkpointData.Get("time").Int(), 0),
+			Pages: checkpointData.Get("pages").Raw,
+		})
+	}
+
+}
+
+// checkpointProject returns the Checkpoint loaded as a read-only Project, for viewing or comparing against. It shares the
+// Project's Camera, as GUI elements drawn in the world are positioned using the current Project's Camera.
+func (project *Project) checkpointProject(checkpoint *Checkpoint) *Project {
+
+	if checkpoint.view != nil {
+		return checkpoint.view
+	}
+
+	// Creating a Project resets the page and card IDs, so they have to be put back afterwards.
+	pageID, cardID := globalPageID, globalCardID
+
+	eventLogOn := globals.EventLog.On
+	globals.EventLog.On = false
+
+	view := NewProject()
+	view.ReadOnly = true
+	view.Loading = true
+	view.Filepath = project.Filepath
+	view.Camera = project.Camera
+	view.TagColors = project.TagColors
+	view.UndoHistory.On = false
+
+	view.deserializePages(gjson.Parse(checkpoint.Pages).Array())
+
+	for _, page := range view.Pages {
+
+		for _, card := range page.Cards {
+			card.DisplayRect.X = card.Rect.X
+			card.DisplayRect.Y = card.Rect.Y
+			card.DisplayRect.W = card.Rect.W
+			card.DisplayRect.H = card.Rect.H
+		}
+
+		page.UpdateLinks()
+
+	}
+
+	pan, zoom := project.Camera.TargetPosition, project.Camera.TargetZoom
+
+	view.settle()
+
+	project.Camera.JumpTo(pan, zoom)
+
+	view.CurrentPage = view.Pages[0]
+	view.Loading = false
+
+	globals.EventLog.On = eventLogOn
+	globalPageID, globalCardID = pageID, cardID
+
+	checkpoint.view = view
+
+	return view
+
+}
+
+// ViewCheckpoint opens the Checkpoint, read-only, in a tab of its own (if it isn't open already) and switches to it. The
+// Project keeps running in the background while the Checkpoint's being viewed.
+func (project *Project) ViewCheckpoint(checkpoint *Checkpoint) {
+
+	view := project.checkpointProject(checkpoint)
+
+	if project.checkpointTabIndex(checkpoint) < 0 {
+		project.CheckpointTabs = append(project.CheckpointTabs, checkpoint)
+	}
+
+	project.rememberTabView()
+
+	project.CheckpointView = view
+	project.CheckpointTabsChanged = true
+
+	view.SetCheckpointPage(view.CurrentPage)
+
+	globals.MenuSystem.Get("prev sub page").Close()
+
+	viewMenu := globals.MenuSystem.Get("checkpoint view")
+	viewMenu.Open()
+
+}
+
+// SetCheckpointPage switches to another Page of a Checkpoint being viewed.
+func (project *Project) SetCheckpointPage(page *Page) {
+	project.CurrentPage = page
+	project.Camera.JumpTo(page.Pan, page.Zoom)
+}
+
+// ShowProjectTab switches back to the Project from a Checkpoint's tab, leaving the tab open.
+func (project *Project) ShowProjectTab() {
+
+	if project.CheckpointView == nil {
+		return
+	}
+
+	project.rememberTabView()
+
+	project.CheckpointView = nil
+	project.CheckpointTabsChanged = true
+
+	project.Camera.JumpTo(project.CurrentPage.Pan, project.CurrentPage.Zoom)
+
+	if project.CurrentPage.UpwardPage != nil {
+		globals.MenuSystem.Get("prev sub page").Open()
+	}
+
+}
+
+// CloseCheckpointTab closes the Checkpoint's tab, switching back to the Project if it was being shown.
+func (project *Project) CloseCheckpointTab(checkpoint *Checkpoint) {
+
+	index := project.checkpointTabIndex(checkpoint)
+	if index < 0 {
+		return
+	}
+
+	if project.CheckpointView != nil && project.CheckpointView == checkpoint.view {
+		project.ShowProjectTab()
+	}
+
+	project.CheckpointTabs = append(project.CheckpointTabs[:index], project.CheckpointTabs[index+1:]...)
+	project.CheckpointTabsChanged = true
+
+	if len(project.CheckpointTabs) == 0 {
+		globals.MenuSystem.Get("checkpoint view").Close()
+	}
+
+}
+
+func (project *Project) checkpointTabIndex(checkpoint *Checkpoint) int {
+	for i, c := range project.CheckpointTabs {
+		if c == checkpoint {
+			return i
+		}
+	}
+	return -1
+}
+
+// rememberTabView stores where the camera is on the Checkpoint being viewed, so it's still there when switching back to its
+// tab. The Project's own Pages keep track of this themselves.
+func (project *Project) rememberTabView() {
+	if view := project.CheckpointView; view != nil {
+		view.CurrentPage.Pan = project.Camera.TargetPosition
+		view.CurrentPage.Zoom = project.Camera.TargetZoom
+	}
+}
+
+// ViewedCheckpoint returns the Checkpoint being viewed, or nil if there isn't one.
+func (project *Project) ViewedCheckpoint() *Checkpoint {
+
+	if project.CheckpointView != nil {
+		for _, checkpoint := range project.Checkpoints {
+			if checkpoint.view == project.CheckpointView {
+				return checkpoint
+			}
+		}
+	}
+
+	return nil
+
+}
+
+// CheckpointComparison lists how the Project's Cards differ from a Checkpoint's. Removed Cards belong to the Checkpoint;
+// the rest belong to the Project.
+type CheckpointComparison struct {
+	Checkpoint *Checkpoint
+	Added      []*Card
+	Removed    []*Card
+	Completed  []*Card
+	Changed    []*Card
+}
+
+// CompareCheckpoint compares the Project against the Checkpoint, matching Cards up by ID.
+func (project *Project) CompareCheckpoint(checkpoint *Checkpoint) *CheckpointComparison {
+
+	comparison := &CheckpointComparison{Checkpoint: checkpoint}
+
+	then := map[int64]*Card{}
+	for _, page := range project.checkpointProject(checkpoint).Pages {
+		for _, card := range page.Cards {
+			if card.Valid {
+				then[card.ID] = card
+			}
+		}
+	}
+
+	now := map[int64]*Card{}
+	for _, page := range project.Pages {
+		if page.ReferenceCount <= 0 {
+			continue
+		}
+		for _, card := range page.Cards {
+			if card.Valid {
+				now[card.ID] = card
+			}
+		}
+	}
+
+	for id, card := range now {
+
+		old, existed := then[id]
+
+		switch {
+		case card.Completed() && (!existed || !old.Completed()):
+			comparison.Completed = append(comparison.Completed, card)
+		case !existed:
+			comparison.Added = append(comparison.Added, card)
+		case old.Serialize() != card.Serialize():
+			comparison.Changed = append(comparison.Changed, card)
+		}
+
+	}
+
+	for id, card := range then {
+		if _, exists := now[id]; !exists {
+			comparison.Removed = append(comparison.Removed, card)
+		}
+	}
+
+	for _, cards := range [][]*Card{comparison.Added, comparison.Removed, comparison.Completed, comparison.Changed} {
+		sort.Slice(cards, func(i, j int) bool { return cards[i].ID < cards[j].ID })
+	}
+
+	return comparison
+
+}
+
+func (project *Project) pageByID(id uint64) *Page {
+	for _, page := range project.Pages {
+		if page.ID == id {
+			return page
+		}
+	}
+	return nil
+}
+
+func (project *Project) cardByID(id int64) *Card {
+	for _, page := range project.Pages {
+		if card := page.CardByID(id); card != nil && card.Valid {
+			return card
+		}
+	}
+	return nil
+}
+
+// restoreCheckpointCards puts the Project's Cards back to how they are in a Checkpoint's Project, recreating any that have
+// since been deleted. It returns the restored Cards.
+func (project *Project) restoreCheckpointCards(sources ...*Card) []*Card {
+
+	restored := []*Card{}
+	pages := map[*Page]bool{}
+
+	for _, source := range sources {
+
+		page := project.pageByID(source.Page.ID)
+		if page == nil {
+			continue
+		}
+
+		card := project.cardByID(source.ID)
+		if card == nil {
+			card = page.CreateCardWithID(source.ID)
+		}
+
+		card.Deserialize(source.Serialize())
+
+		restored = append(restored, card)
+		pages[card.Page] = true
+
+	}
+
+	// Links are recreated once every Card's been restored, as they might link to each other.
+	for page := range pages {
+		page.UpdateLinks()
+		page.UpdateStacks = true
+	}
+
+	for _, card := range restored {
+		project.UndoHistory.Capture(NewUndoState(card))
+		card.CreateUndoState = false
+	}
+
+	if len(restored) > 0 {
+		project.Modified = true
+	}
+
+	return restored
+
+}
+
+// RestoreCheckpointCard returns a single Card to how it is in a Checkpoint; source is the Card in the Checkpoint's Project.
+func (project *Project) RestoreCheckpointCard(source *Card) {
+
+	if len(project.restoreCheckpointCards(source)) == 0 {
+		globals.EventLog.Log("Error: Couldn't restore %s, as its page no longer exists.", undoCardName(source))
+		return
+	}
+
+	globals.EventLog.Log("Restored %s from checkpoint.", undoCardName(source))
+
+}
+
+// RestoreCheckpointPage returns a Page to how it is in a Checkpoint; Cards that have been added to it since are deleted.
+// source is the Page in the Checkpoint's Project.
+func (project *Project) RestoreCheckpointPage(source *Page) {
+
+	page := project.pageByID(source.ID)
+	if page == nil {
+		globals.EventLog.Log("Error: Couldn't restore page '%s', as it no longer exists.", source.Name)
+		return
+	}
+
+	cards := []*Card{}
+	kept := map[int64]bool{}
+
+	for _, card := range source.Cards {
+		if card.Valid {
+			cards = append(cards, card)
+			kept[card.ID] = true
+		}
+	}
+
+	added := []*Card{}
+	for _, card := range page.Cards {
+		if card.Valid && !kept[card.ID] {
+			added = append(added, card)
+		}
+	}
+
+	eventLogOn := globals.EventLog.On
+	globals.EventLog.On = false
+
+	page.DeleteCards(added...)
+	project.restoreCheckpointCards(cards...)
+
+	if page.Name != source.Name {
+		project.UndoHistory.Track(page)
+		page.Name = source.Name
+		project.UndoHistory.Capture(NewUndoState(page))
+	}
+
+	globals.EventLog.On = eventLogOn
+
+	project.Modified = true
+
+	globals.EventLog.Log("Restored page '%s' from checkpoint.", source.Name)
+
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Command is a single action that can be run from the command palette; commands are gathered from the Keybindings and from
+// the buttons in every Menu.
+type Command struct {
+	Name string
+	Keys string
+	Run  func()
+}
+
+// AvailableCommands returns every Command that can currently be run from the command palette.
+func AvailableCommands() []*Command {
+
+	commands := []*Command{}
+
+	for _, s := range globals.Keybindings.ShortcutsInOrder {
+
+		shortcut := s
+
+		// Held shortcuts are modifiers for other actions, so they don't do anything by themselves.
+		if !shortcut.Enabled || shortcut.triggerMode == TriggerModeHold || shortcut.Name == KBCommandPalette {
+			continue
+		}
+
+		commands = append(commands, &Command{
+			Name: shortcut.Name,
+			Keys: shortcut.KeysToString(),
+			Run:  func() { globals.Keybindings.Trigger(shortcut.Name) },
+		})
+
+	}
+
+	menuNames := []string{}
+	for name := range globals.MenuSystem.MenuNames {
+		menuNames = append(menuNames, name)
+	}
+	sort.Strings(menuNames)
+
+	for _, menuName := range menuNames {
+
+		menu := globals.MenuSystem.MenuNames[menuName]
+
+		if menuName == "command palette" {
+			continue
+		}
+
+		for pageName, page := range menu.Pages {
+
+			for _, row := range page.Rows {
+
+				for elementName, element := range row.Elements {
+
+					button, ok := element.(*Button)
+
+					// The keybinding buttons on the input settings page just rebind or reset their shortcuts.
+					shortcutName := strings.TrimSuffix(strings.TrimSuffix(elementName, "-b"), "-d")
+					if _, isShortcutButton := globals.Keybindings.Shortcuts[shortcutName]; isShortcutButton && shortcutName != elementName {
+						continue
+					}
+
+					if !ok || button.OnPressed == nil || button.Disabled || strings.TrimSpace(button.Label.TextAsString()) == "" {
+						continue
+					}
+
+					name := strings.Title(menuName) + ": "
+					if pageName != "root" {
+						name += strings.Title(pageName) + ": "
+					}
+					name += button.Label.TextAsString()
+
+					m := menu
+					p := pageName
+
+					commands = append(commands, &Command{
+						Name: name,
+						Run: func() {
+							// Buttons on sub-pages generally expect their page to be showing.
+							if p != "root" {
+								m.Open()
+								m.SetPage(p)
+							}
+							button.OnPressed()
+						},
+					})
+
+				}
+
+			}
+
+		}
+
+	}
+
+	return commands
+
+}
+
+// FuzzyScore returns how well the pattern matches the text, or -1 if it doesn't match at all. Every character of the pattern has to
+// appear in the text in order; consecutive characters and characters at the start of words score higher.
+func FuzzyScore(pattern, text string) int {
+
+	pattern = strings.ToLower(pattern)
+	textRunes := []rune(strings.ToLower(text))
+
+	score := 0
+	ti := 0
+	prevMatch := -2
+
+	for _, pc := range pattern {
+
+		if unicode.IsSpace(pc) {
+			continue
+		}
+
+		found := false
+
+		for ; ti < len(textRunes); ti++ {
+
+			if textRunes[ti] == pc {
+
+				score++
+
+				if ti == prevMatch+1 {
+					score += 2
+				}
+
+				if ti == 0 || !unicode.IsLetter(textRunes[ti-1]) {
+					score += 3
+				}
+
+				prevMatch = ti
+				ti++
+				found = true
+				break
+
+			}
+
+		}
+
+		if !found {
+			return -1
+		}
+
+	}
+
+	return score
+
+}
+
+// SearchCommands returns the Commands matching the search text, best matches first; recently used Commands are ranked above
+// the rest.
+func SearchCommands(commands []*Command, search string) []*Command {
+
+	recency := map[string]int{}
+	for i, name := range globals.RecentCommands {
+		recency[name] = len(globals.RecentCommands) - i
+	}
+
+	scores := map[*Command]int{}
+	results := []*Command{}
+
+	for _, command := range commands {
+		if score := FuzzyScore(search, command.Name); score >= 0 {
+			scores[command] = score
+			results = append(results, command)
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if recency[a.Name] != recency[b.Name] {
+			return recency[a.Name] > recency[b.Name]
+		}
+		if scores[a] != scores[b] {
+			return scores[a] > scores[b]
+		}
+		return a.Name < b.Name
+	})
+
+	return results
+
+}
+
+// RunCommand runs the Command and moves it to the top of the recently used list.
+func RunCommand(command *Command) {
+
+	for i, name := range globals.RecentCommands {
+		if name == command.Name {
+			globals.RecentCommands = append(globals.RecentCommands[:i], globals.RecentCommands[i+1:]...)
+			break
+		}
+	}
+
+	globals.RecentCommands = append([]string{command.Name}, globals.RecentCommands...)
+
+	if len(globals.RecentCommands) > 10 {
+		globals.RecentCommands = globals.RecentCommands[:10]
+	}
+
+	SaveSettings()
+
+	command.Run()
+
+}
@@ -25,6 +25,7 @@ const (
 	ContentTypeMap      = "Map"
 	ContentTypeTable    = "Table"
 	ContentTypeSubpage  = "Sub-Page"
+	ContentTypeFrame    = "Frame"
 
 	TriggerTypeSet    = "Set"
 	TriggerTypeToggle = "Toggle"
@@ -38,6 +39,7 @@ type Contents interface {
 	Color() Color
 	DefaultSize() Point
 	Trigger(triggerType string)
+	StartEditing() bool
 }
 
 type DefaultContents struct {
@@ -66,8 +68,58 @@ func (dc *DefaultContents) Draw() {
 
 func (dc *DefaultContents) Trigger(triggerType string) {}
 
+// drawCornerLabel draws a small label in the top-right corner of the Card.
+func (dc *DefaultContents) drawCornerLabel(text string) {
+
+	labelWidth := globals.TextRenderer.MeasureText([]rune(text), 0.5).X + 16
+	if labelWidth < 32 {
+		labelWidth = 32
+	}
+
+	dstPoint := Point{dc.Card.DisplayRect.X + dc.Card.DisplayRect.W - labelWidth, dc.Card.DisplayRect.Y}
+	DrawLabel(dc.Card.Page.Project.Camera.TranslatePoint(dstPoint), text)
+
+}
+
+// statusText adds to text shown in a corner label how long's been spent on a task and its streak, if it's recurring.
+func (dc *DefaultContents) statusText(text string) string {
+
+	parts := []string{}
+	if text != "" {
+		parts = append(parts, text)
+	}
+
+	if timeSpent := dc.Card.TimeSpent(); timeSpent >= time.Minute {
+		parts = append(parts, formatTimeSpent(timeSpent))
+	}
+
+	if dc.Card.Recurrence() != nil {
+		parts = append(parts, fmt.Sprintf("Streak: %d", dc.Card.Streak()))
+	}
+
+	return strings.Join(parts, "  ")
+
+}
+
 func (dc *DefaultContents) ReceiveMessage(msg *Message) {}
 
+// StartEditing starts editing the first editable Label in the Contents, returning if there was one to edit.
+func (dc *DefaultContents) StartEditing() bool {
+
+	for _, row := range dc.Container.Rows {
+		for _, element := range row.ElementOrder {
+			if label, ok := element.(*Label); ok && label.Editable {
+				label.Editing = true
+				label.Selection.SelectAll()
+				return true
+			}
+		}
+	}
+
+	return false
+
+}
+
 type CheckboxContents struct {
 	DefaultContents
 	Label                        *Label
@@ -183,9 +235,14 @@ func (cc *CheckboxContents) Draw() {
 
 	cc.Checkbox.Clickable = len(dependentCards) == 0
 
+	labelText := ""
+
 	if len(dependentCards) > 0 {
-		dstPoint := Point{cc.Card.DisplayRect.X + cc.Card.DisplayRect.W - 32, cc.Card.DisplayRect.Y}
-		DrawLabel(cc.Card.Page.Project.Camera.TranslatePoint(dstPoint), fmt.Sprintf("%d/%d", int(completed), int(maximum)))
+		labelText = fmt.Sprintf("%d/%d", int(completed), int(maximum))
+	}
+
+	if labelText = cc.statusText(labelText); labelText != "" {
+		cc.drawCornerLabel(labelText)
 	}
 
 	// for _, button := range cc.URLButtons.Buttons {
@@ -467,12 +524,14 @@ func (nc *NumberedContents) Draw() {
 
 	nc.DefaultContents.Draw()
 
-	if nc.Max.Property.AsFloat() > 0 {
+	perc := ""
 
-		dstPoint := Point{nc.Card.DisplayRect.X + nc.Card.DisplayRect.W - 32, nc.Card.DisplayRect.Y}
-		perc := strconv.FormatFloat(float64(p*100), 'f', 0, 32) + "%"
-		DrawLabel(nc.Card.Page.Project.Camera.TranslatePoint(dstPoint), perc)
+	if nc.Max.Property.AsFloat() > 0 {
+		perc = strconv.FormatFloat(float64(p*100), 'f', 0, 32) + "%"
+	}
 
+	if labelText := nc.statusText(perc); labelText != "" {
+		nc.drawCornerLabel(labelText)
 	}
 
 }
@@ -591,6 +650,8 @@ type SoundContents struct {
 	Resource *Resource
 	Sound    *Sound
 	SeekBar  *Scrollbar
+
+	resourceVersion int
 }
 
 func NewSoundContents(card *Card) *SoundContents {
@@ -725,6 +786,8 @@ func (sc *SoundContents) Update() {
 				globals.EventLog.Log("Error: Couldn't load [%s] as sound resource", sc.Resource.Name)
 				sc.Resource = nil
 				return
+			} else if sc.Sound != nil && sc.resourceVersion != sc.Resource.Version {
+				sc.ReloadSound()
 			} else if sc.Sound == nil || sc.Sound.Empty {
 				if sc.Sound != nil {
 					sc.Sound.Destroy()
@@ -751,6 +814,7 @@ func (sc *SoundContents) Update() {
 				}
 
 				sc.Sound = sc.Resource.AsNewSound()
+				sc.resourceVersion = sc.Resource.Version
 				sc.SeekBar.SetValue(0)
 
 				var nextInLoop *Card
@@ -818,6 +882,10 @@ func (sc *SoundContents) LoadFile() {
 
 		sc.Resource = newRes
 
+		if newRes != nil {
+			newRes.Watched = true
+		}
+
 		if sc.Sound != nil {
 			sc.Sound.Pause()
 			sc.Sound.Destroy()
@@ -829,6 +897,35 @@ func (sc *SoundContents) LoadFile() {
 
 }
 
+// ReloadSound swaps in a new stream after the sound file's been changed on disk, keeping the playback position and whether it's
+// playing.
+func (sc *SoundContents) ReloadSound() {
+
+	sc.resourceVersion = sc.Resource.Version
+
+	sound, err := sc.Resource.NewSound()
+	if err != nil {
+		globals.EventLog.Log("Error: Couldn't reload sound [%s]: %s", sc.Resource.Name, err.Error())
+		return
+	}
+
+	position := sc.Sound.Position()
+
+	sc.Sound.Pause()
+	sc.Sound.Destroy()
+
+	sc.Sound = sound
+
+	if position < sc.Sound.Length() {
+		sc.Sound.Seek(position)
+	}
+
+	if sc.Playing {
+		sc.Sound.Play()
+	}
+
+}
+
 func (sc *SoundContents) LoadFileFrom(filepath string) {
 
 	sc.Card.Properties.Get("filepath").Set(filepath)
@@ -930,6 +1027,8 @@ type ImageContents struct {
 	Buttons       []*IconButton
 	Resource      *Resource
 	DefaultImage  *Resource
+
+	resourceVersion int
 }
 
 func NewImageContents(card *Card) *ImageContents {
@@ -1043,6 +1142,22 @@ func (ic *ImageContents) Update() {
 
 			}
 
+			ic.resourceVersion = resource.Version
+
+		} else if ic.resourceVersion != resource.Version {
+
+			// The file's been changed on disk; the Card keeps its size, and just swaps what it's showing.
+			if ic.GifPlayer != nil {
+				ic.GifPlayer.Destroy()
+				ic.GifPlayer = nil
+			}
+
+			if resource.IsGIF() {
+				ic.GifPlayer = NewGifPlayer(resource.AsGIF())
+			}
+
+			ic.resourceVersion = resource.Version
+
 		}
 
 		if resource.TempFile {
@@ -1149,8 +1264,11 @@ func (ic *ImageContents) LoadFile() {
 
 	if newResource := globals.Resources.Get(fp); newResource != nil {
 
+		newResource.Watched = true
+
 		if ic.Resource == nil || ic.Resource != newResource {
 			ic.Resource = newResource
+			ic.resourceVersion = newResource.Version
 			ic.LoadedImage = false
 
 			if ic.Card.Page.Project.Loading {
@@ -1187,6 +1305,14 @@ func (ic *ImageContents) ReceiveMessage(msg *Message) {
 	}
 }
 
+// Timer modes, as stored in a Timer Card's "mode group" property.
+const (
+	TimerModeStopwatch = iota
+	TimerModeCountdown
+	TimerModePomodoro
+	TimerModeDeadline
+)
+
 type TimerContents struct {
 	DefaultContents
 	Name               *Label
@@ -1202,6 +1328,20 @@ type TimerContents struct {
 	TriggerMode        *IconButtonGroup
 	AlarmSound         *Sound
 	PercentageComplete float32
+	SessionStart       time.Time // When the current time tracking session started; zero if there isn't one
+	wasRunning         bool
+	PomodoroPhase      string
+	PomodoroCycle      int // Work cycles completed in the current set
+	PomodoroStatus     *Label
+	PomodoroRows       []*ContainerRow
+	DeadlineLabel      *Label
+	DeadlineRow        *ContainerRow
+	resumedFrom        time.Time // When a Timer that was running when the project was saved was started; zero once it's caught up
+	resumedWith        time.Duration
+	persistedRunning   bool
+	persistedValue     time.Duration
+	persistedPhase     string
+	persistPending     bool
 }
 
 func NewTimerContents(card *Card) *TimerContents {
@@ -1241,16 +1381,26 @@ func NewTimerContents(card *Card) *TimerContents {
 
 	}
 
-	tc.Mode = NewIconButtonGroup(&sdl.FRect{0, 0, 64, 32}, true, func(index int) {
+	tc.Mode = NewIconButtonGroup(&sdl.FRect{0, 0, 128, 32}, true, func(index int) {
 		tc.Running = false
-		if index == 0 {
+		if index == TimerModeStopwatch {
 			globals.EventLog.Log("Timer Mode changed to Stopwatch.")
-		} else {
+		} else if index == TimerModeCountdown {
 			globals.EventLog.Log("Timer Mode changed to Countdown.")
+		} else if index == TimerModeDeadline {
+			globals.EventLog.Log("Timer Mode changed to Deadline.")
+		} else {
+			globals.EventLog.Log("Timer Mode changed to Pomodoro.")
+			tc.resetPomodoro()
+			if minH := tc.DefaultSize().Y + (globals.GridSize * pomodoroRowCount); card.Rect.H < minH {
+				card.Recreate(card.Rect.W, minH)
+			}
 		}
 	}, card.Properties.Get("mode group"),
 		&sdl.Rect{48, 192, 32, 32},
 		&sdl.Rect{80, 192, 32, 32},
+		&sdl.Rect{80, 64, 32, 32},
+		&sdl.Rect{176, 64, 32, 32},
 	)
 
 	tc.TriggerMode = NewIconButtonGroup(&sdl.FRect{0, 0, 96, 32}, true, func(index int) {
@@ -1309,15 +1459,32 @@ func NewTimerContents(card *Card) *TimerContents {
 	row.Add("", NewLabel("Trigger:  ", nil, true, AlignRight))
 	row.Add("trigger", tc.TriggerMode)
 
+	tc.addPomodoroRows()
+	tc.addDeadlineRow()
+
+	tc.restoreState()
+
 	return tc
 }
 
 func (tc *TimerContents) Update() {
 
+	if !tc.Card.Page.Project.Loading {
+		tc.catchUp()
+	}
+
+	tc.persistState()
+	tc.trackSession()
+
+	modeGroup := int(tc.Card.Properties.Get("mode group").AsFloat())
+
 	gs := globals.GridSize
 	r := tc.Name.Rectangle()
 	r.W = tc.Card.Rect.W - gs
 	r.H = tc.Card.Rect.H - (gs * 5)
+	if modeGroup == TimerModePomodoro {
+		r.H -= gs * pomodoroRowCount
+	}
 	if r.H < gs {
 		r.H = gs
 	}
@@ -1331,9 +1498,7 @@ func (tc *TimerContents) Update() {
 		tc.TimerValue += time.Duration(globals.DeltaTime * float32(time.Second))
 		tc.Pie.FillPercent += globals.DeltaTime
 
-		modeGroup := int(tc.Card.Properties.Get("mode group").AsFloat())
-
-		if tc.TimerValue > tc.MaxTime && modeGroup == 1 {
+		if tc.TimerValue > tc.MaxTime && modeGroup == TimerModeCountdown {
 
 			elapsedMessage := "Timer [" + tc.Name.TextAsString() + "] elapsed."
 
@@ -1342,44 +1507,18 @@ func (tc *TimerContents) Update() {
 			tc.Pie.FillPercent = 0
 			tc.TimerValue = 0
 
-			triggerMode := int(tc.Card.Properties.Get("trigger mode").AsFloat())
-
-			tt := TriggerTypeToggle
-			if triggerMode == 1 {
-				tt = TriggerTypeSet
-			} else if triggerMode == 2 {
-				tt = TriggerTypeClear
-			}
-
-			for _, link := range tc.Card.Links {
-
-				if link.End.Contents != nil {
-					link.End.Contents.Trigger(tt)
-				}
-			}
-
-			if globals.Settings.Get(SettingsFocusOnElapsedTimers).AsBool() {
-				tc.Card.Page.Project.Camera.FocusOn(false, tc.Card)
-			}
-			if globals.Settings.Get(SettingsNotifyOnElapsedTimers).AsBool() && globals.WindowFlags&sdl.WINDOW_INPUT_FOCUS == 0 {
-				beeep.Notify("MasterPlan", elapsedMessage, "")
-			}
-
-			if globals.Settings.Get(SettingsPlayAlarmSound).AsBool() {
-				if tc.AlarmSound != nil {
-					tc.AlarmSound.Destroy()
-				}
-				tc.AlarmSound = globals.Resources.Get(LocalRelativePath("assets/alarm.wav")).AsNewSound()
-				tc.AlarmSound.Play()
-			}
+			tc.triggerLinks()
+			tc.alarm(elapsedMessage)
 
+		} else if modeGroup == TimerModePomodoro {
+			tc.updatePomodoro()
 		}
 
 	}
 
-	modeGroup := tc.Card.Properties.Get("mode group").AsFloat()
+	modeGroup = int(tc.Card.Properties.Get("mode group").AsFloat())
 
-	if modeGroup == 0 {
+	if modeGroup != TimerModeCountdown {
 		tc.ClockMaxTime.SetRectangle(&sdl.FRect{0, 0, 0, 0})
 		tc.ClockMaxTime.Editable = false
 	} else {
@@ -1387,7 +1526,38 @@ func (tc *TimerContents) Update() {
 		tc.ClockMaxTime.Editable = true
 	}
 
-	tc.ClockLabel.SetText([]rune(formatTime(tc.TimerValue, false)))
+	for _, row := range tc.PomodoroRows {
+		row.Visible = modeGroup == TimerModePomodoro
+	}
+
+	// Deadlines run on their own, so they don't need starting or restarting.
+	tc.DeadlineRow.Visible = modeGroup == TimerModeDeadline
+	for _, row := range tc.Container.FindRows("start button", false) {
+		row.Visible = modeGroup != TimerModeDeadline
+	}
+
+	clockRect := tc.ClockLabel.Rectangle()
+	clockRect.W = 128
+	if modeGroup == TimerModeDeadline {
+		clockRect.W = 192
+	}
+	tc.ClockLabel.SetRectangle(clockRect)
+
+	if modeGroup == TimerModeDeadline {
+		tc.Running = false
+		if deadline, ok := tc.Deadline(); ok {
+			tc.ClockLabel.SetText([]rune(formatCountdown(time.Until(deadline))))
+		} else {
+			tc.ClockLabel.SetText([]rune("--"))
+		}
+		tc.updateDeadline()
+	} else if modeGroup == TimerModePomodoro {
+		// Pomodoros count down through each phase.
+		tc.ClockLabel.SetText([]rune(formatTime(tc.pomodoroLength(tc.PomodoroPhase)-tc.TimerValue, false)))
+		tc.PomodoroStatus.SetText([]rune(tc.pomodoroStatusText()))
+	} else {
+		tc.ClockLabel.SetText([]rune(formatTime(tc.TimerValue, false)))
+	}
 
 	if tc.Card.IsSelected() {
 
@@ -1408,17 +1578,64 @@ func (tc *TimerContents) Update() {
 
 }
 
+// triggerLinks triggers the Cards the Timer links to, according to its trigger mode.
+func (tc *TimerContents) triggerLinks() {
+
+	triggerMode := int(tc.Card.Properties.Get("trigger mode").AsFloat())
+
+	tt := TriggerTypeToggle
+	if triggerMode == 1 {
+		tt = TriggerTypeSet
+	} else if triggerMode == 2 {
+		tt = TriggerTypeClear
+	}
+
+	for _, link := range tc.Card.Links {
+
+		if link.End.Contents != nil {
+			link.End.Contents.Trigger(tt)
+		}
+	}
+
+}
+
+// alarm alerts the user that the Timer's gone off, depending on their settings.
+func (tc *TimerContents) alarm(message string) {
+
+	if globals.Settings.Get(SettingsFocusOnElapsedTimers).AsBool() {
+		tc.Card.Page.Project.Camera.FocusOn(false, tc.Card)
+	}
+	if globals.Settings.Get(SettingsNotifyOnElapsedTimers).AsBool() && globals.WindowFlags&sdl.WINDOW_INPUT_FOCUS == 0 {
+		beeep.Notify("MasterPlan", message, "")
+	}
+
+	if globals.Settings.Get(SettingsPlayAlarmSound).AsBool() {
+		if tc.AlarmSound != nil {
+			tc.AlarmSound.Destroy()
+		}
+		tc.AlarmSound = globals.Resources.Get(LocalRelativePath("assets/alarm.wav")).AsNewSound()
+		tc.AlarmSound.Play()
+	}
+
+}
+
 func (tc *TimerContents) Draw() {
 
 	p := float32(0)
 
+	modeGroup := int(tc.Card.Properties.Get("mode group").AsFloat())
+
 	// Numbered mode
-	if int(tc.Card.Properties.Get("mode group").AsFloat()) != 0 && tc.MaxTime > 0 {
+	if modeGroup == TimerModeCountdown && tc.MaxTime > 0 {
 
 		if tc.TimerValue > 0 {
 			p = float32(tc.TimerValue) / float32(tc.MaxTime)
 		}
 
+	} else if modeGroup == TimerModePomodoro && tc.TimerValue > 0 {
+		p = float32(tc.TimerValue) / float32(tc.pomodoroLength(tc.PomodoroPhase))
+	} else if deadline, ok := tc.Deadline(); ok && modeGroup == TimerModeDeadline && !time.Now().Before(deadline) {
+		p = 1
 	}
 	tc.PercentageComplete += (p - tc.PercentageComplete) * 6 * globals.DeltaTime
 
@@ -1468,6 +1685,9 @@ func (tc *TimerContents) ReceiveMessage(msg *Message) {
 		if tc.AlarmSound != nil {
 			tc.AlarmSound.UpdateVolume()
 		}
+	} else if msg.Type == MessageUndoRedo {
+		// Undoing puts back the Timer's saved state as it was, so it's brought up to date again.
+		tc.persistPending = true
 	}
 }
 
@@ -2407,6 +2627,7 @@ func NewSubPageContents(card *Card) *SubPageContents {
 	sb.NameLabel.DrawLineUnderTitle = false
 
 	project := sb.Card.Page.Project
+	created := false
 
 	if sb.Card.Properties.Has("subpage") {
 		spID := uint64(sb.Card.Properties.Get("subpage").AsFloat())
@@ -2420,12 +2641,21 @@ func NewSubPageContents(card *Card) *SubPageContents {
 		sb.SubPage = project.AddPage()
 		index := project.PageIndex(sb.SubPage)
 		sb.Card.Properties.Get("subpage").Set(float64(index)) // We have to set as a float because JSON only has floats as numbers, not ints
+		created = true
 	}
 	sb.SubPage.UpwardPage = sb.Card.Page
 
+	// A new sub-page gets a lane of its own, so undoing its creation removes it again.
+	if created {
+		project.UndoHistory.Capture(NewUndoState(sb.SubPage))
+	}
+
 	sb.NameLabel.OnClickOut = func() {
-		if sb.SubPage != nil {
+		if sb.SubPage != nil && sb.SubPage.Name != sb.NameLabel.TextAsString() {
+			history := sb.SubPage.Project.UndoHistory
+			history.Track(sb.SubPage)
 			sb.SubPage.Name = sb.NameLabel.TextAsString()
+			history.Capture(NewUndoState(sb.SubPage))
 		}
 	}
 	sb.NameLabel.Property = card.Properties.Get("description")
@@ -2546,3 +2776,203 @@ func (sb *SubPageContents) DefaultSize() Point {
 }
 
 func (sb *SubPageContents) Trigger(triggerType string) {}
+
+type FrameContents struct {
+	DefaultContents
+	NameLabel    *Label
+	Contained    []*Card
+	dragging     bool
+	prevPosition Point
+}
+
+func NewFrameContents(card *Card) *FrameContents {
+
+	fc := &FrameContents{
+		DefaultContents: newDefaultContents(card),
+		Contained:       []*Card{},
+	}
+
+	fc.NameLabel = NewLabel("New Frame", nil, true, AlignLeft)
+	fc.NameLabel.Editable = true
+	fc.NameLabel.RegexString = RegexNoNewlines
+	fc.NameLabel.Property = card.Properties.Get("description")
+
+	row := fc.Container.AddRow(AlignLeft)
+	row.Add("name", fc.NameLabel)
+
+	return fc
+
+}
+
+func (fc *FrameContents) Update() {
+
+	// Frames sit behind every other Card, including Maps.
+	fc.Card.Depth = -2
+
+	rect := fc.NameLabel.Rectangle()
+	rect.W = fc.Container.Rect.W
+	fc.NameLabel.SetRectangle(rect)
+
+	if fc.Card.Dragging {
+
+		if !fc.dragging {
+
+			// The Card's already moved by the time the drag's registered here, so we get the position it was dragged from instead.
+			fc.prevPosition = fc.Card.DragStart.Sub(fc.Card.DragStartOffset)
+			region := fc.Region()
+			fc.Contained = fc.ContainedCardsAt(&sdl.FRect{fc.prevPosition.X, fc.prevPosition.Y, region.W, region.H})
+			fc.dragging = true
+
+			for _, card := range fc.Contained {
+				card.Page.Project.UndoHistory.Capture(NewUndoState(card))
+			}
+
+		}
+
+		fc.moveContained()
+
+	} else if fc.dragging {
+
+		// The frame's position has been locked to the grid by now, so we move the contained Cards one last time before locking them as well.
+		fc.moveContained()
+
+		for _, card := range fc.Contained {
+			card.LockPosition()
+			card.CreateUndoState = true
+		}
+
+		fc.Contained = []*Card{}
+		fc.dragging = false
+
+	}
+
+	if fc.Card.Resizing != "" {
+		fc.clampContained()
+	}
+
+	fc.DefaultContents.Update()
+
+}
+
+// moveContained moves the Cards contained within the frame by however much the frame has moved since the last call.
+func (fc *FrameContents) moveContained() {
+
+	delta := Point{fc.Card.Rect.X, fc.Card.Rect.Y}.Sub(fc.prevPosition)
+
+	for _, card := range fc.Contained {
+		// Selected Cards are already being dragged along with the frame.
+		if !card.Dragging {
+			card.Rect.X += delta.X
+			card.Rect.Y += delta.Y
+		}
+	}
+
+	fc.prevPosition = Point{fc.Card.Rect.X, fc.Card.Rect.Y}
+
+}
+
+// clampContained keeps the Cards contained within the frame inside of it as it's resized.
+func (fc *FrameContents) clampContained() {
+
+	rect := fc.Card.Rect
+
+	for _, card := range fc.Contained {
+
+		if card.Rect.X+card.Rect.W > rect.X+rect.W {
+			card.Rect.X = rect.X + rect.W - card.Rect.W
+		}
+		if card.Rect.X < rect.X {
+			card.Rect.X = rect.X
+		}
+
+		if card.Rect.Y+card.Rect.H > rect.Y+rect.H {
+			card.Rect.Y = rect.Y + rect.H - card.Rect.H
+		}
+		if card.Rect.Y < rect.Y {
+			card.Rect.Y = rect.Y
+		}
+
+	}
+
+}
+
+// Region returns the area the frame covers, which is its full, uncollapsed size even when collapsed.
+func (fc *FrameContents) Region() *sdl.FRect {
+
+	rect := *fc.Card.Rect
+
+	if fc.Card.Collapsed != CollapsedNone && fc.Card.UncollapsedSize.Y > 0 {
+		rect.H = fc.Card.UncollapsedSize.Y
+	}
+
+	return &rect
+
+}
+
+// ContainedCards returns the Cards that lie fully within the frame's region.
+func (fc *FrameContents) ContainedCards() []*Card {
+	return fc.ContainedCardsAt(fc.Region())
+}
+
+func (fc *FrameContents) ContainedCardsAt(region *sdl.FRect) []*Card {
+
+	cards := []*Card{}
+	added := map[*Card]bool{}
+
+	for _, card := range fc.Card.Page.Grid.Select(region).Cards() {
+
+		if card == fc.Card || !card.Valid || added[card] {
+			continue
+		}
+
+		if card.Rect.X >= region.X && card.Rect.Y >= region.Y && card.Rect.X+card.Rect.W <= region.X+region.W && card.Rect.Y+card.Rect.H <= region.Y+region.H {
+			cards = append(cards, card)
+			added[card] = true
+		}
+
+	}
+
+	return cards
+
+}
+
+func (fc *FrameContents) ReceiveMessage(msg *Message) {
+
+	if msg.Type == MessageResizeStart {
+
+		fc.Contained = fc.ContainedCards()
+
+		for _, card := range fc.Contained {
+			card.Page.Project.UndoHistory.Capture(NewUndoState(card))
+		}
+
+	} else if msg.Type == MessageResizeCompleted {
+
+		for _, card := range fc.Contained {
+			card.LockPosition()
+			card.CreateUndoState = true
+		}
+
+		fc.Contained = []*Card{}
+
+	}
+
+}
+
+func (fc *FrameContents) Color() Color {
+
+	color := getThemeColor(GUIFrameColor)
+
+	if fc.Card.CustomColor != nil {
+		alpha := color[3]
+		color = fc.Card.CustomColor.Clone()
+		color[3] = alpha
+	}
+
+	return color
+}
+
+func (fc *FrameContents) DefaultSize() Point {
+	gs := globals.GridSize
+	return Point{gs * 16, gs * 12}
+}
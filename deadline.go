@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// DeadlineFormat is how a Deadline Timer's date and time are displayed and stored.
+const DeadlineFormat = "2006-01-02 15:04"
+
+// Formats a deadline can be typed in; dates without a time are due at midnight.
+var deadlineInputFormats = []string{
+	DeadlineFormat,
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"2006/01/02 15:04",
+	"2006/01/02",
+}
+
+// ParseDeadline parses a deadline typed into a Timer, in local time.
+func ParseDeadline(text string) (time.Time, bool) {
+
+	text = strings.Join(strings.Fields(text), " ")
+
+	for _, format := range deadlineInputFormats {
+		if t, err := time.ParseInLocation(format, text, time.Local); err == nil {
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+
+}
+
+// formatCountdown formats the time left until a deadline in days, hours, and minutes (or hours, minutes, and seconds once
+// it's under a day away).
+func formatCountdown(d time.Duration) string {
+
+	if d < 0 {
+		d = 0
+	}
+
+	totalSeconds := int(d.Seconds())
+	days := totalSeconds / 86400
+	hours := (totalSeconds % 86400) / 3600
+	minutes := (totalSeconds % 3600) / 60
+	seconds := totalSeconds % 60
+
+	if days > 0 {
+		return fmt.Sprintf("%dd %02dh %02dm", days, hours, minutes)
+	}
+
+	return fmt.Sprintf("%02dh %02dm %02ds", hours, minutes, seconds)
+
+}
+
+func (tc *TimerContents) addDeadlineRow() {
+
+	prop := tc.Card.Properties.Get("deadline")
+	if !prop.IsString() {
+		prop.SetRaw(time.Now().AddDate(0, 0, 7).Format(DeadlineFormat))
+	}
+
+	tc.DeadlineLabel = NewLabel(prop.AsString(), &sdl.FRect{0, 0, 192, 32}, true, AlignCenter)
+	tc.DeadlineLabel.Property = prop
+	tc.DeadlineLabel.Editable = true
+	tc.DeadlineLabel.RegexString = RegexNoNewlines
+	tc.DeadlineLabel.MaxLength = 19
+
+	tc.DeadlineLabel.OnClickOut = func() {
+
+		deadline, ok := ParseDeadline(tc.DeadlineLabel.TextAsString())
+
+		if !ok {
+			globals.EventLog.Log("Error: Couldn't read deadline [%s]; it should be written like %s.", tc.DeadlineLabel.TextAsString(), DeadlineFormat)
+			if deadline, ok = tc.Deadline(); ok {
+				tc.DeadlineLabel.SetTextRaw([]rune(deadline.Format(DeadlineFormat)))
+			}
+			return
+		}
+
+		tc.DeadlineLabel.SetTextRaw([]rune(deadline.Format(DeadlineFormat)))
+
+		// A deadline that's already passed when it's set doesn't go off.
+		tc.Card.Properties.Get("deadline fired").SetRaw(!deadline.After(time.Now()))
+
+	}
+
+	row := tc.Container.AddRow(AlignCenter)
+	row.Add("", NewLabel("Due:  ", nil, true, AlignRight))
+	row.Add("deadline", tc.DeadlineLabel)
+	tc.DeadlineRow = row
+
+}
+
+// Deadline returns when the Timer's deadline is, if it's been set to a valid date.
+func (tc *TimerContents) Deadline() (time.Time, bool) {
+	prop := tc.Card.Properties.Get("deadline")
+	if !prop.IsString() {
+		return time.Time{}, false
+	}
+	return ParseDeadline(prop.AsString())
+}
+
+// updateDeadline goes off once the deadline's passed. Whether it's gone off is saved with the Card, so a deadline that passed
+// while MasterPlan was closed goes off once the project's opened again.
+func (tc *TimerContents) updateDeadline() {
+
+	deadline, ok := tc.Deadline()
+
+	if !ok || tc.DeadlineLabel.Editing || tc.Card.Page.Project.Loading || time.Now().Before(deadline) {
+		return
+	}
+
+	fired := tc.Card.Properties.Get("deadline fired")
+
+	if fired.AsBool() {
+		return
+	}
+
+	fired.SetRaw(true)
+	tc.Card.Page.Project.Modified = true
+
+	tc.triggerLinks()
+
+	// Deadlines are checked constantly while MasterPlan's open, so one that's long gone must have passed while it was closed.
+	if time.Since(deadline) > time.Minute {
+		message := fmt.Sprintf("Deadline [%s] passed at %s, while MasterPlan was closed.", tc.Name.TextAsString(), deadline.Format(TimestampDisplayFormat))
+		tc.listDeadline(deadline, message)
+		tc.catchUpAlarm(message)
+		return
+	}
+
+	message := fmt.Sprintf("Deadline [%s] passed at %s.", tc.Name.TextAsString(), deadline.Format(TimestampDisplayFormat))
+
+	tc.listDeadline(deadline, message)
+
+	globals.EventLog.Log(message)
+	tc.alarm(message)
+
+}
+
+// listDeadline puts a deadline that's passed in the reminders list alongside the Project's reminders, so it can be snoozed
+// (which sets a reminder on the Timer) or focused on from there.
+func (tc *TimerContents) listDeadline(deadline time.Time, message string) {
+	tc.Card.Page.Project.Reminders.Add(tc.Card, deadline, message)
+	globals.MenuSystem.Get("reminders").Open()
+}
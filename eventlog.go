@@ -25,6 +25,10 @@ func (event *Event) Done() bool {
 type EventLog struct {
 	On     bool
 	Events []*Event
+
+	// The last message logged, and the frame it was logged on; the UndoHistory uses this to label its frames.
+	LastMessage      string
+	LastMessageFrame int64
 }
 
 func NewEventLog() *EventLog {
@@ -58,6 +62,9 @@ func (eventLog *EventLog) Log(text string, variables ...interface{}) {
 
 	}
 
+	eventLog.LastMessage = output
+	eventLog.LastMessageFrame = globals.Frame
+
 	// Log the event so that it goes to the log file in addition to the messages at the bottom-left
 	log.Println(output)
 
@@ -48,7 +48,9 @@ type Globals struct {
 	SettingsLoaded bool
 	Keybindings    *Keybindings
 	RecentFiles    []string
+	RecentCommands []string
 	HTTPClient     *http.Client
+	RPCServer      *RPCServer
 
 	DebugMode          bool
 	TriggerReloadFonts bool
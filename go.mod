@@ -33,6 +33,7 @@ require (
 	github.com/ulikunitz/xz v0.5.10 // indirect
 	github.com/veandco/go-sdl2 v0.4.12
 	github.com/xi2/xz v0.0.0-20171230120015-48954b6210f8 // indirect
+	go.starlark.net v0.0.0-20220203230714-bb14e151c28f
 	golang.design/x/clipboard v0.6.0
 	golang.org/x/exp v0.0.0-20220128181451-c853b6ddb95e // indirect
 	golang.org/x/mobile v0.0.0-20220112015953-858099ff7816 // indirect
@@ -105,6 +105,11 @@ func (selection GridSelection) Cells() []*GridCell {
 	for y := selection.Start.Y; y < selection.End.Y; y++ {
 		for x := selection.Start.X; x < selection.End.X; x++ {
 
+			// Skip cells that lie outside of the Grid entirely
+			if int(y)+offsetY < 0 || int(y)+offsetY >= len(selection.Grid.Cells) || int(x)+offsetX < 0 || int(x)+offsetX >= len(selection.Grid.Cells[0]) {
+				continue
+			}
+
 			cells = append(cells, selection.Grid.Cells[int(y)+offsetY][int(x)+offsetX])
 
 		}
@@ -222,10 +227,15 @@ func (grid *Grid) CardsAbove(card *Card) []*Card {
 
 	cards := []*Card{}
 
+	// Frames don't take part in stacking, as they lie behind the Cards stacked within them.
+	if card.ContentType == ContentTypeFrame {
+		return cards
+	}
+
 	selection := grid.Select(&sdl.FRect{card.Rect.X, card.Rect.Y - globals.GridSize, card.Rect.W, globals.GridSize})
 
 	for _, c := range selection.Cards() {
-		if card != c {
+		if card != c && c.ContentType != ContentTypeFrame {
 			cards = append(cards, c)
 		}
 	}
@@ -238,10 +248,14 @@ func (grid *Grid) CardsBelow(card *Card) []*Card {
 
 	cards := []*Card{}
 
+	if card.ContentType == ContentTypeFrame {
+		return cards
+	}
+
 	selection := grid.Select(&sdl.FRect{card.Rect.X, card.Rect.Y + card.Rect.H, card.Rect.W, globals.GridSize})
 
 	for _, c := range selection.Cards() {
-		if card != c {
+		if card != c && c.ContentType != ContentTypeFrame {
 			cards = append(cards, c)
 		}
 	}
@@ -51,6 +51,7 @@ const (
 	GUIBlankImageColor = "Blank Image Color"
 	GUIMapColor        = "Map Color"
 	GUISubBoardColor   = "Sub-Page Color"
+	GUIFrameColor      = "Frame Color"
 )
 
 var availableThemes []string = []string{}
@@ -1160,7 +1161,7 @@ func (label *Label) Update() {
 
 				globals.State = StateTextEditing
 
-				if ClickedOutRect(activeRect, label.WorldSpace) || globals.Keyboard.Key(sdl.K_ESCAPE).Pressed() {
+				if ClickedOutRect(activeRect, label.WorldSpace) || globals.Keybindings.Pressed(KBStopEditing) {
 					label.Editing = false
 					globals.State = StateNeutral
 					label.Selection.Select(0, 0)
@@ -2582,6 +2583,111 @@ func (pie *Pie) SetRectangle(rect *sdl.FRect) {
 
 func (pie *Pie) Destroy() {}
 
+// ChartSeries is a set of values drawn in a Chart, either as a line or as bars. Start is the index along the Chart the values
+// start at.
+type ChartSeries struct {
+	Values []float32
+	Start  int
+	Color  Color
+	Bars   bool
+}
+
+// Chart draws one or more series of values over a shared X axis, scaled to fit its rectangle.
+type Chart struct {
+	Rect   *sdl.FRect
+	Series []*ChartSeries
+	Labels []string // Labels for the X axis; the first and last are drawn
+}
+
+func NewChart(rect *sdl.FRect) *Chart {
+	chart := &Chart{
+		Rect:   &sdl.FRect{},
+		Series: []*ChartSeries{},
+	}
+	chart.SetRectangle(rect)
+	return chart
+}
+
+func (chart *Chart) Update() {}
+
+func (chart *Chart) Draw() {
+
+	fontColor := getThemeColor(GUIFontColor)
+
+	bgColor := getThemeColor(GUIBGColor)
+	FillRect(chart.Rect.X, chart.Rect.Y, chart.Rect.W, chart.Rect.H, bgColor)
+	ThickRect(int32(chart.Rect.X), int32(chart.Rect.Y), int32(chart.Rect.W), int32(chart.Rect.H), 2, getThemeColor(GUIGridColor))
+
+	count := 0
+	maxValue := float32(0)
+
+	for _, series := range chart.Series {
+		if end := series.Start + len(series.Values); end > count {
+			count = end
+		}
+		for _, v := range series.Values {
+			if v > maxValue {
+				maxValue = v
+			}
+		}
+	}
+
+	if count == 0 {
+		globals.TextRenderer.QuickRenderText("No data", Point{chart.Rect.X + chart.Rect.W/2, chart.Rect.Y + chart.Rect.H/2 - 16}, 1, fontColor, AlignCenter)
+		return
+	}
+
+	if maxValue == 0 {
+		maxValue = 1
+	}
+
+	margin := float32(24)
+	area := &sdl.FRect{chart.Rect.X + margin, chart.Rect.Y + margin, chart.Rect.W - margin*2, chart.Rect.H - margin*2}
+	step := area.W / float32(count)
+
+	point := func(index int, value float32) Point {
+		return Point{area.X + step*(float32(index)+0.5), area.Y + area.H - (value/maxValue)*area.H}
+	}
+
+	for _, series := range chart.Series {
+
+		for i, v := range series.Values {
+
+			index := series.Start + i
+
+			if series.Bars {
+				p := point(index, v)
+				FillRect(p.X-step*0.3, p.Y, step*0.6, area.Y+area.H-p.Y, series.Color)
+			} else if i > 0 {
+				ThickLine(point(index-1, series.Values[i-1]), point(index, v), 2, series.Color)
+			}
+
+		}
+
+	}
+
+	globals.TextRenderer.QuickRenderText(strconv.FormatFloat(float64(maxValue), 'f', -1, 32), Point{chart.Rect.X + 4, chart.Rect.Y}, 0.5, fontColor, AlignLeft)
+
+	if len(chart.Labels) > 0 {
+		globals.TextRenderer.QuickRenderText(chart.Labels[0], Point{area.X, area.Y + area.H}, 0.5, fontColor, AlignLeft)
+		globals.TextRenderer.QuickRenderText(chart.Labels[len(chart.Labels)-1], Point{area.X + area.W, area.Y + area.H}, 0.5, fontColor, AlignRight)
+	}
+
+}
+
+func (chart *Chart) Rectangle() *sdl.FRect {
+	return chart.Rect
+}
+
+func (chart *Chart) SetRectangle(rect *sdl.FRect) {
+	chart.Rect.X = rect.X
+	chart.Rect.Y = rect.Y
+	chart.Rect.W = rect.W
+	chart.Rect.H = rect.H
+}
+
+func (chart *Chart) Destroy() {}
+
 type ColorWheel struct {
 	Rect          *sdl.FRect
 	HueStrip      *sdl.Surface
@@ -1,7 +1,12 @@
 package main
 
 import (
+	"errors"
+	"os"
+	"path/filepath"
 	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/tidwall/gjson"
@@ -40,6 +45,7 @@ const (
 	KBNewTimerCard    = "New Timer Card"
 	KBNewMapCard      = "New Map Card"
 	KBNewSubpageCard  = "New Sub-Page Card"
+	KBNewFrameCard    = "New Frame Card"
 
 	KBAddToSelection      = "Add to Selection Modifier"
 	KBRemoveFromSelection = "Remove From Selection Modifier"
@@ -61,11 +67,24 @@ const (
 	KBCollapseCard = "Card: Collapse"
 	KBLinkCard     = "Card: Link With Line"
 	KBUnlinkCard   = "Card: Remove All Links"
+	KBEditCard     = "Card: Start Editing"
+
+	KBSelectCardUp    = "Select Card Above"
+	KBSelectCardRight = "Select Card Right"
+	KBSelectCardDown  = "Select Card Below"
+	KBSelectCardLeft  = "Select Card Left"
+	KBSelectNextCard  = "Select Next Card in Stack Order"
+	KBSelectPrevCard  = "Select Prev. Card in Stack Order"
+	KBSlideCardUp     = "Slide Selected Cards Up"
+	KBSlideCardRight  = "Slide Selected Cards Right"
+	KBSlideCardDown   = "Slide Selected Cards Down"
+	KBSlideCardLeft   = "Slide Selected Cards Left"
 
 	KBCopyText      = "Textbox: Copy Selected Text"
 	KBCutText       = "Textbox: Cut Selected Text"
 	KBPasteText     = "Textbox: Paste Copied Text"
 	KBSelectAllText = "Textbox: Select All Text"
+	KBStopEditing   = "Textbox: Stop Editing"
 
 	KBUndo = "Undo"
 	KBRedo = "Redo"
@@ -96,6 +115,9 @@ const (
 	KBFindNext = "Find: Next Card"
 	KBFindPrev = "Find: Prev. Card"
 
+	KBCommandPalette = "Open Command Palette"
+	KBRunLastScript  = "Run Last Script"
+
 	KBTimerStartStop = "Timer: Start / Stop Timer"
 
 	KBSubpageOpen = "Sub-Page: Open / Close"
@@ -166,6 +188,95 @@ const (
 	TriggerModeHold
 )
 
+// Shortcut contexts; these describe when a Shortcut can trigger, so that two Shortcuts bound to the same keys are only reported as
+// clashing if they can trigger at the same time. Shortcuts that only trigger for selected Cards of a specific type use that
+// type's ContentType constant as their context.
+const (
+	KBContextGlobal      = "Global"
+	KBContextBoard       = "Board"
+	KBContextTextEditing = "Text Editing"
+	KBContextMapEditing  = "Map Editing"
+)
+
+// KBProfilePath is where bundled keybinding presets live.
+const KBProfilePath = "assets/keybindings"
+
+// ChordTimeout is how long (in seconds) a partially entered chord stays pending before it's cancelled.
+const ChordTimeout = 1.5
+
+// KeyCombo is a single key pressed while holding any number of modifier keys; the leading steps of chord shortcuts (like the "Ctrl+K" in
+// "Ctrl+K, C") are made up of these.
+type KeyCombo struct {
+	Key       sdl.Keycode
+	Modifiers []sdl.Keycode
+}
+
+func (combo KeyCombo) String() string {
+	name := ""
+	for _, mod := range combo.Modifiers {
+		name += sdl.GetKeyName(mod) + "+"
+	}
+	return name + sdl.GetKeyName(combo.Key)
+}
+
+// Pressed returns if the combo's key was pressed this frame while its modifiers are held.
+func (combo KeyCombo) Pressed(key sdl.Keycode) bool {
+
+	if key != combo.Key {
+		return false
+	}
+
+	for _, mod := range combo.Modifiers {
+		if !globals.Keyboard.Key(mod).HeldRaw() {
+			return false
+		}
+	}
+
+	return true
+
+}
+
+func (combo KeyCombo) Equals(other KeyCombo) bool {
+
+	if combo.Key != other.Key || len(combo.Modifiers) != len(other.Modifiers) {
+		return false
+	}
+
+	for i := range combo.Modifiers {
+		if combo.Modifiers[i] != other.Modifiers[i] {
+			return false
+		}
+	}
+
+	return true
+
+}
+
+func (combo KeyCombo) Serialize() string {
+	data, _ := sjson.Set("", "key", combo.Key)
+	if len(combo.Modifiers) > 0 {
+		data, _ = sjson.Set(data, "mods", combo.Modifiers)
+	}
+	return data
+}
+
+func DeserializeKeyCombo(data string) KeyCombo {
+	combo := KeyCombo{Key: sdl.Keycode(gjson.Get(data, "key").Int())}
+	for _, mod := range gjson.Get(data, "mods").Array() {
+		combo.Modifiers = append(combo.Modifiers, sdl.Keycode(mod.Int()))
+	}
+	return combo
+}
+
+// IsModifierKey returns if the key is one that's only ever held alongside other keys (Ctrl, Shift, Alt, or the OS key).
+func IsModifierKey(key sdl.Keycode) bool {
+	switch key {
+	case sdl.K_LCTRL, sdl.K_RCTRL, sdl.K_LSHIFT, sdl.K_RSHIFT, sdl.K_LALT, sdl.K_RALT, sdl.K_LGUI, sdl.K_RGUI:
+		return true
+	}
+	return false
+}
+
 type Shortcut struct {
 	Name               string
 	Enabled            bool
@@ -179,6 +290,14 @@ type Shortcut struct {
 	MouseButton        uint8
 	DefaultMouseButton uint8
 
+	// Chord is the sequence of key combos that have to be pressed, in order, before the shortcut's own key can trigger it.
+	Chord        []KeyCombo
+	DefaultChord []KeyCombo
+
+	// Context is when the Shortcut can trigger; Overrides lists Shortcuts that this one takes precedence over when both could trigger.
+	Context   string
+	Overrides []string
+
 	DefaultSet bool
 }
 
@@ -187,6 +306,7 @@ func NewShortcut(name string) *Shortcut {
 	shortcut := &Shortcut{
 		Name:    name,
 		Enabled: true,
+		Context: KBContextBoard,
 	}
 
 	return shortcut
@@ -201,6 +321,7 @@ func (shortcut *Shortcut) SetKeys(code sdl.Keycode, modCodes ...sdl.Keycode) {
 	shortcut.Key = code
 	shortcut.Modifiers = append([]sdl.Keycode{}, modCodes...)
 	shortcut.MouseButton = 255
+	shortcut.Chord = nil
 
 	if !shortcut.DefaultSet {
 		shortcut.DefaultKey = shortcut.Key
@@ -215,6 +336,7 @@ func (shortcut *Shortcut) SetButton(buttonIndex uint8, modCodes ...sdl.Keycode)
 	shortcut.MouseButton = buttonIndex
 	shortcut.Key = -1
 	shortcut.Modifiers = append([]sdl.Keycode{}, modCodes...)
+	shortcut.Chord = nil
 
 	if !shortcut.DefaultSet {
 		shortcut.DefaultMouseButton = buttonIndex
@@ -225,9 +347,19 @@ func (shortcut *Shortcut) SetButton(buttonIndex uint8, modCodes ...sdl.Keycode)
 
 }
 
+// SetChord sets the leading key combos that have to be entered before the shortcut's key triggers it. SetKeys() clears the chord, so
+// this should be called after it.
+func (shortcut *Shortcut) SetChord(chord ...KeyCombo) {
+	shortcut.Chord = append([]KeyCombo{}, chord...)
+}
+
 func (shortcut *Shortcut) KeysToString() string {
 	name := ""
 
+	for _, combo := range shortcut.Chord {
+		name += combo.String() + ", "
+	}
+
 	for _, mod := range shortcut.Modifiers {
 		name += sdl.GetKeyName(mod) + "+"
 	}
@@ -256,6 +388,7 @@ func (shortcut *Shortcut) Keys() []sdl.Keycode {
 }
 
 func (shortcut *Shortcut) ConsumeKeys() {
+	delete(globals.Keybindings.triggered, shortcut.Name)
 	if shortcut.MouseButton < 255 {
 		globals.Mouse.Button(shortcut.MouseButton).Consume()
 	} else {
@@ -277,6 +410,11 @@ func (shortcut *Shortcut) Serialize() string {
 		data, _ = sjson.Set(data, "mods", shortcut.Modifiers)
 	}
 
+	// Shortcuts without a chord serialize just as they did before chords existed, so older settings files load as-is.
+	for i, combo := range shortcut.Chord {
+		data, _ = sjson.SetRaw(data, "chord."+strconv.Itoa(i), combo.Serialize())
+	}
+
 	return data
 
 }
@@ -295,16 +433,28 @@ func (shortcut *Shortcut) Deserialize(data string) {
 		shortcut.SetButton(uint8(gjson.Get(data, "mouse").Int()), mods...)
 	} else {
 		shortcut.SetKeys(sdl.Keycode(gjson.Get(data, "key").Int()), mods...)
+
+		chord := []KeyCombo{}
+		for _, combo := range gjson.Get(data, "chord").Array() {
+			chord = append(chord, DeserializeKeyCombo(combo.Raw))
+		}
+		shortcut.SetChord(chord...)
 	}
 
 }
 
 func (shortcut *Shortcut) IsDefault() bool {
 
-	if shortcut.Key != shortcut.DefaultKey || shortcut.MouseButton != shortcut.DefaultMouseButton || len(shortcut.DefaultModifiers) != len(shortcut.Modifiers) {
+	if shortcut.Key != shortcut.DefaultKey || shortcut.MouseButton != shortcut.DefaultMouseButton || len(shortcut.DefaultModifiers) != len(shortcut.Modifiers) || len(shortcut.DefaultChord) != len(shortcut.Chord) {
 		return false
 	}
 
+	for i := range shortcut.Chord {
+		if !shortcut.Chord[i].Equals(shortcut.DefaultChord[i]) {
+			return false
+		}
+	}
+
 	mods := map[sdl.Keycode]bool{}
 
 	for _, mod := range shortcut.Modifiers {
@@ -325,6 +475,89 @@ func (shortcut *Shortcut) ResetToDefault() {
 	shortcut.Key = shortcut.DefaultKey
 	shortcut.Modifiers = shortcut.DefaultModifiers
 	shortcut.MouseButton = shortcut.DefaultMouseButton
+	shortcut.Chord = append([]KeyCombo{}, shortcut.DefaultChord...)
+
+}
+
+// SameInput returns if both Shortcuts are bound to the same key or mouse button, modifiers, and chord.
+func (shortcut *Shortcut) SameInput(other *Shortcut) bool {
+
+	if shortcut.MouseButton != other.MouseButton || (shortcut.MouseButton == 255 && shortcut.Key != other.Key) {
+		return false
+	}
+
+	if len(shortcut.Modifiers) != len(other.Modifiers) || len(shortcut.Chord) != len(other.Chord) {
+		return false
+	}
+
+	mods := map[sdl.Keycode]bool{}
+	for _, mod := range shortcut.Modifiers {
+		mods[mod] = true
+	}
+
+	for _, mod := range other.Modifiers {
+		if !mods[mod] {
+			return false
+		}
+	}
+
+	for i := range shortcut.Chord {
+		if !shortcut.Chord[i].Equals(other.Chord[i]) {
+			return false
+		}
+	}
+
+	return true
+
+}
+
+// Clashes returns if the two Shortcuts are bound to the same input and can trigger at the same time. Shortcuts that are both
+// held (like modifiers) are meant to be combined, so they don't clash.
+func (shortcut *Shortcut) Clashes(other *Shortcut) bool {
+
+	if shortcut == other || !shortcut.Enabled || !other.Enabled || !shortcut.SameInput(other) {
+		return false
+	}
+
+	if shortcut.triggerMode == TriggerModeHold && other.triggerMode == TriggerModeHold {
+		return false
+	}
+
+	for _, name := range shortcut.Overrides {
+		if name == other.Name {
+			return false
+		}
+	}
+
+	for _, name := range other.Overrides {
+		if name == shortcut.Name {
+			return false
+		}
+	}
+
+	return contextsOverlap(shortcut.Context, other.Context)
+
+}
+
+// contextsOverlap returns if Shortcuts in the two contexts can trigger at the same time.
+func contextsOverlap(a, b string) bool {
+
+	if a == b || a == KBContextGlobal || b == KBContextGlobal {
+		return true
+	}
+
+	// Nothing but global shortcuts trigger while editing text.
+	if a == KBContextTextEditing || b == KBContextTextEditing {
+		return false
+	}
+
+	// Board shortcuts also work while editing maps and alongside every card-specific shortcut; card-specific shortcuts for
+	// different types only act on their own Cards, so sharing keys between them (like Space) is fine.
+	if a == KBContextBoard || b == KBContextBoard {
+		return true
+	}
+
+	return (a == KBContextMapEditing && b == ContentTypeMap) || (a == ContentTypeMap && b == KBContextMapEditing)
 
 }
 
@@ -345,6 +578,18 @@ type Keybindings struct {
 	Shortcuts              map[string]*Shortcut
 	KeyShortcutsByFamily   map[sdl.Keycode][]*Shortcut
 	MouseShortcutsByFamily map[uint8][]*Shortcut
+
+	// PendingChord is the sequence of key combos entered so far towards a chord shortcut.
+	PendingChord  []KeyCombo
+	chordTime     float64
+	chordResolved bool
+
+	// Rebinding should be set while keys are being captured for rebinding so that entering them doesn't start a chord.
+	Rebinding bool
+
+	// Shortcuts passed to Trigger() are queued up and then count as pressed for the whole of the next frame.
+	triggerQueue map[string]bool
+	triggered    map[string]bool
 }
 
 func NewKeybindings() *Keybindings {
@@ -353,6 +598,8 @@ func NewKeybindings() *Keybindings {
 		Shortcuts:              map[string]*Shortcut{},
 		KeyShortcutsByFamily:   map[sdl.Keycode][]*Shortcut{},
 		MouseShortcutsByFamily: map[uint8][]*Shortcut{},
+		triggerQueue:           map[string]bool{},
+		triggered:              map[string]bool{},
 	}
 	kb.Default()
 	kb.UpdateShortcutFamilies()
@@ -367,6 +614,21 @@ func (kb *Keybindings) DefineKeyShortcut(bindingName string, keyCode sdl.Keycode
 	return sc
 }
 
+// DefineChordShortcut defines a shortcut that triggers when its key is pressed after entering the given chord (e.g. "Ctrl+K, C").
+func (kb *Keybindings) DefineChordShortcut(bindingName string, chord []KeyCombo, keyCode sdl.Keycode, mods ...sdl.Keycode) *Shortcut {
+	sc := kb.DefineKeyShortcut(bindingName, keyCode, mods...)
+	sc.SetChord(chord...)
+	sc.DefaultChord = append([]KeyCombo{}, chord...)
+	return sc
+}
+
+// SetContext sets the context for the named Shortcuts.
+func (kb *Keybindings) SetContext(context string, bindingNames ...string) {
+	for _, name := range bindingNames {
+		kb.Shortcuts[name].Context = context
+	}
+}
+
 func (kb *Keybindings) DefineMouseShortcut(bindingName string, mouseButton uint8) *Shortcut {
 	sc := NewShortcut(bindingName)
 	sc.SetButton(mouseButton)
@@ -421,6 +683,7 @@ func (kb *Keybindings) Default() {
 	kb.DefineKeyShortcut(KBNewTimerCard, sdl.K_6, sdl.K_LSHIFT)
 	kb.DefineKeyShortcut(KBNewMapCard, sdl.K_7, sdl.K_LSHIFT)
 	kb.DefineKeyShortcut(KBNewSubpageCard, sdl.K_8, sdl.K_LSHIFT)
+	kb.DefineKeyShortcut(KBNewFrameCard, sdl.K_9, sdl.K_LSHIFT)
 
 	kb.DefineKeyShortcut(KBAddToSelection, sdl.K_LSHIFT).triggerMode = TriggerModeHold
 	kb.DefineKeyShortcut(KBRemoveFromSelection, sdl.K_LALT).triggerMode = TriggerModeHold
@@ -443,6 +706,19 @@ func (kb *Keybindings) Default() {
 	kb.DefineKeyShortcut(KBSelectAllText, sdl.K_a, sdl.K_LCTRL)
 
 	kb.DefineKeyShortcut(KBCollapseCard, sdl.K_c, sdl.K_LSHIFT)
+	kb.DefineKeyShortcut(KBEditCard, sdl.K_RETURN)
+	kb.DefineKeyShortcut(KBStopEditing, sdl.K_ESCAPE)
+
+	kb.DefineKeyShortcut(KBSelectCardUp, sdl.K_UP)
+	kb.DefineKeyShortcut(KBSelectCardRight, sdl.K_RIGHT)
+	kb.DefineKeyShortcut(KBSelectCardDown, sdl.K_DOWN)
+	kb.DefineKeyShortcut(KBSelectCardLeft, sdl.K_LEFT)
+	kb.DefineKeyShortcut(KBSelectNextCard, sdl.K_TAB)
+	kb.DefineKeyShortcut(KBSelectPrevCard, sdl.K_TAB, sdl.K_LSHIFT)
+	kb.DefineKeyShortcut(KBSlideCardUp, sdl.K_UP, sdl.K_LALT)
+	kb.DefineKeyShortcut(KBSlideCardRight, sdl.K_RIGHT, sdl.K_LALT)
+	kb.DefineKeyShortcut(KBSlideCardDown, sdl.K_DOWN, sdl.K_LALT)
+	kb.DefineKeyShortcut(KBSlideCardLeft, sdl.K_LEFT, sdl.K_LALT)
 
 	kb.DefineKeyShortcut(KBUndo, sdl.K_z, sdl.K_LCTRL)
 	kb.DefineKeyShortcut(KBRedo, sdl.K_z, sdl.K_LCTRL, sdl.K_LSHIFT)
@@ -473,10 +749,27 @@ func (kb *Keybindings) Default() {
 	kb.DefineKeyShortcut(KBFindNext, sdl.K_f, sdl.K_LCTRL)
 	kb.DefineKeyShortcut(KBFindPrev, sdl.K_f, sdl.K_LCTRL, sdl.K_LSHIFT)
 
+	kb.DefineKeyShortcut(KBCommandPalette, sdl.K_p, sdl.K_LCTRL, sdl.K_LSHIFT)
+	kb.DefineKeyShortcut(KBRunLastScript, sdl.K_F5)
+
 	kb.DefineKeyShortcut(KBSubpageOpen, sdl.K_BACKQUOTE)
 
 	kb.DefineKeyShortcut(KBResizeMultiple, sdl.K_LSHIFT).triggerMode = TriggerModeHold
 
+	kb.SetContext(KBContextGlobal, KBDebugToggle, KBTakeScreenshot, KBUndo, KBRedo, KBToggleFullscreen, KBWindowSizeSmall, KBWindowSizeNormal, KBFindNext, KBFindPrev)
+	kb.SetContext(KBContextTextEditing, KBCopyText, KBCutText, KBPasteText, KBSelectAllText, KBStopEditing)
+	kb.SetContext(KBContextMapEditing, KBPickColor, KBMapQuickLineTool)
+	kb.SetContext(ContentTypeMap, KBMapNoTool, KBMapPencilTool, KBMapEraserTool, KBMapFillTool, KBMapLineTool, KBMapPalette)
+	kb.SetContext(ContentTypeCheckbox, KBCheckboxToggleCompletion)
+	kb.SetContext(ContentTypeNumbered, KBNumberedIncrement, KBNumberedDecrement)
+	kb.SetContext(ContentTypeSound, KBSoundPlay, KBSoundStopAll, KBSoundJumpForward, KBSoundJumpBackward)
+	kb.SetContext(ContentTypeTimer, KBTimerStartStop)
+	kb.SetContext(ContentTypeSubpage, KBSubpageOpen)
+	kb.SetContext(ContentTypeImage, KBUnlockImageASR)
+
+	// Tab opens a selected Map's palette rather than moving on to the next Card.
+	kb.Shortcuts[KBMapPalette].Overrides = []string{KBSelectNextCard}
+
 	kb.UpdateShortcutFamilies()
 
 }
@@ -526,6 +819,100 @@ func (kb *Keybindings) UpdateShortcutFamilies() {
 
 }
 
+// Update advances, resolves, or cancels the pending chord according to the keys pressed this frame; it should be called once per frame
+// after input has been handled.
+func (kb *Keybindings) Update() {
+
+	kb.triggered = kb.triggerQueue
+	kb.triggerQueue = map[string]bool{}
+
+	if kb.chordResolved || kb.Rebinding || globals.State == StateTextEditing {
+		kb.CancelChord()
+	}
+
+	if kb.Rebinding || globals.State == StateTextEditing {
+		return
+	}
+
+	if len(kb.PendingChord) > 0 && globals.Time-kb.chordTime > ChordTimeout {
+		globals.EventLog.Log("Chord %s timed out.", kb.PendingChordString())
+		kb.CancelChord()
+	}
+
+	for _, key := range globals.Keyboard.PressedKeys() {
+
+		if IsModifierKey(key) {
+			continue
+		}
+
+		var next *KeyCombo
+		resolves := false
+
+		for _, sc := range kb.ShortcutsInOrder {
+
+			if !sc.Enabled || len(sc.Chord) < len(kb.PendingChord) || !kb.chordStartsWith(sc.Chord) {
+				continue
+			}
+
+			if len(sc.Chord) > len(kb.PendingChord) {
+				if step := sc.Chord[len(kb.PendingChord)]; step.Pressed(key) {
+					next = &step
+				}
+			} else if len(sc.Chord) > 0 && sc.Key == key {
+				resolves = true
+			}
+
+		}
+
+		if next != nil {
+			kb.PendingChord = append(kb.PendingChord, *next)
+			kb.chordTime = globals.Time
+		} else if resolves {
+			// The shortcut itself triggers this frame through Pressed(); the chord is cleared on the next one.
+			kb.chordResolved = true
+		} else if len(kb.PendingChord) > 0 {
+			// Any other key cancels the chord without doing anything else.
+			globals.EventLog.Log("%s, %s is not a shortcut.", kb.PendingChordString(), sdl.GetKeyName(key))
+			globals.Keyboard.Key(key).Consume()
+			kb.CancelChord()
+		}
+
+	}
+
+}
+
+// Trigger makes the named Shortcut count as pressed on the next frame, as though its keys had been pressed.
+func (kb *Keybindings) Trigger(bindingName string) {
+	kb.triggerQueue[bindingName] = true
+}
+
+// chordStartsWith returns if the chord given begins with the currently pending chord.
+func (kb *Keybindings) chordStartsWith(chord []KeyCombo) bool {
+	for i, combo := range kb.PendingChord {
+		if i >= len(chord) || !combo.Equals(chord[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (kb *Keybindings) CancelChord() {
+	kb.PendingChord = []KeyCombo{}
+	kb.chordResolved = false
+}
+
+// PendingChordString returns the pending chord in text form, or an empty string if no chord is being entered.
+func (kb *Keybindings) PendingChordString() string {
+	text := ""
+	for i, combo := range kb.PendingChord {
+		if i > 0 {
+			text += ", "
+		}
+		text += combo.String()
+	}
+	return text
+}
+
 func (kb *Keybindings) Pressed(bindingName string) bool {
 
 	sc := kb.Shortcuts[bindingName]
@@ -534,6 +921,20 @@ func (kb *Keybindings) Pressed(bindingName string) bool {
 		return false
 	}
 
+	if kb.triggered[bindingName] {
+		return true
+	}
+
+	if len(sc.Chord) > 0 {
+		// Chord shortcuts only trigger when their whole chord has been entered, and not on the same frame its last step was.
+		if len(kb.PendingChord) != len(sc.Chord) || !kb.chordStartsWith(sc.Chord) || kb.chordTime == globals.Time {
+			return false
+		}
+	} else if len(kb.PendingChord) > 0 && sc.triggerMode == TriggerModePress {
+		// While a chord is being entered, regular shortcuts don't trigger.
+		return false
+	}
+
 	if sc.MouseButton < 255 {
 
 		for _, familyShortcut := range kb.MouseShortcutsByFamily[sc.MouseButton] {
@@ -590,6 +991,81 @@ func (kb *Keybindings) Pressed(bindingName string) bool {
 
 }
 
+// Conflicts returns the Shortcuts that clash with the named one.
+func (kb *Keybindings) Conflicts(bindingName string) []*Shortcut {
+
+	conflicts := []*Shortcut{}
+	sc := kb.Shortcuts[bindingName]
+
+	for _, other := range kb.ShortcutsInOrder {
+		if sc.Clashes(other) {
+			conflicts = append(conflicts, other)
+		}
+	}
+
+	return conflicts
+
+}
+
+// ResetToDefault resets all Shortcuts to their default bindings.
+func (kb *Keybindings) ResetToDefault() {
+	for _, shortcut := range kb.Shortcuts {
+		shortcut.ResetToDefault()
+	}
+	kb.UpdateShortcutFamilies()
+}
+
+// ExportProfile writes all keybindings to a standalone profile file that can be shared and imported with ImportProfile().
+func (kb *Keybindings) ExportProfile(filePath string) error {
+
+	name := strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+
+	data, _ := sjson.Set("{}", "name", name)
+	data, _ = sjson.Set(data, "version", globals.Version.String())
+	data, _ = sjson.SetRaw(data, "keybindings", kb.Serialize())
+
+	return os.WriteFile(filePath, []byte(gjson.Get(data, "@pretty").String()), 0644)
+
+}
+
+// ImportProfile loads keybindings from a profile file. Shortcuts the profile doesn't mention are reset to their defaults, so profiles
+// can list only the bindings they change; the settings file itself can also be imported as a profile.
+func (kb *Keybindings) ImportProfile(filePath string) error {
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	if !gjson.ValidBytes(data) || !gjson.GetBytes(data, "keybindings").IsObject() {
+		return errors.New("file is not a keybinding profile")
+	}
+
+	kb.ResetToDefault()
+	kb.Deserialize(string(data))
+	kb.UpdateShortcutFamilies()
+
+	return nil
+
+}
+
+// KeybindingPresets returns the names of the bundled keybinding presets.
+func KeybindingPresets() []string {
+
+	presets := []string{}
+
+	files, _ := os.ReadDir(LocalRelativePath(KBProfilePath))
+
+	for _, file := range files {
+		if !file.IsDir() && filepath.Ext(file.Name()) == ".json" {
+			presets = append(presets, strings.TrimSuffix(file.Name(), ".json"))
+		}
+	}
+
+	return presets
+
+}
+
 func (kb *Keybindings) Serialize() string {
 
 	serialized := "{}"
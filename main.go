@@ -12,8 +12,10 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"runtime/pprof"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -45,7 +47,8 @@ var cpuProfileStart = time.Time{}
 
 func init() {
 
-	if releaseMode != "dev" {
+	// The rpc subcommand talks to an already-running instance, so its output needs to stay on the terminal.
+	if releaseMode != "dev" && !rpcCommand() {
 
 		// Redirect STDERR and STDOUT to log.txt in release mode
 
@@ -93,6 +96,7 @@ func init() {
 	globals.MenuSystem = NewMenuSystem()
 	globals.Keybindings = NewKeybindings()
 	globals.RecentFiles = []string{}
+	globals.RecentCommands = []string{}
 	globals.Settings = NewProgramSettings()
 	globals.HTTPClient = &http.Client{
 		Timeout: time.Second * 10,
@@ -100,8 +104,16 @@ func init() {
 
 }
 
+func rpcCommand() bool {
+	return len(os.Args) > 1 && os.Args[1] == "rpc"
+}
+
 func main() {
 
+	if rpcCommand() {
+		os.Exit(RunRPCClient(os.Args[2:]))
+	}
+
 	// We want to defer a function to recover out of a crash if in release mode.
 	// We do this because by default, Go's stderr points directly to the OS's syserr buffer.
 	// By deferring this function and recovering out of the crash, we can grab the crashlog by
@@ -309,6 +321,12 @@ func main() {
 
 	}
 
+	globals.RPCServer = NewRPCServer()
+
+	if globals.Settings.Get(SettingsAutomationServer).AsBool() {
+		globals.RPCServer.Start()
+	}
+
 	for !quit {
 
 		globals.MenuSystem.Get("main").Pages["root"].FindElement("time label", false).(*Label).SetText([]rune(time.Now().Format("Mon Jan 2 2006")))
@@ -344,6 +362,8 @@ func main() {
 
 		handleEvents()
 
+		globals.Keybindings.Update()
+
 		// currentTime := time.Now()
 
 		// handleMouseInputs()
@@ -381,7 +401,8 @@ func main() {
 			globals.DebugMode = !globals.DebugMode
 		}
 
-		if globals.Keyboard.Key(sdl.K_F5).Pressed() {
+		// F5 runs the last script, so profiling's on F6, and only in debug mode.
+		if globals.DebugMode && globals.Keyboard.Key(sdl.K_F6).Pressed() {
 			profileCPU()
 		}
 
@@ -401,6 +422,10 @@ func main() {
 
 		globals.MenuSystem.Update()
 
+		globals.RPCServer.Update()
+
+		globals.Resources.Update()
+
 		globals.Project.Update()
 
 		globals.Keybindings.On = true
@@ -476,6 +501,19 @@ func main() {
 		msgSize := float32(1)
 		eventY := globals.ScreenSize.Y
 
+		// Show the chord being entered at the bottom of the event log until it's finished or cancelled.
+		if pending := globals.Keybindings.PendingChordString(); pending != "" {
+
+			text := pending + ", ..."
+			textSize := globals.TextRenderer.MeasureText([]rune(text), msgSize)
+
+			FillRect(0, eventY-textSize.Y, textSize.X, textSize.Y, getThemeColor(GUIMenuColor))
+			globals.TextRenderer.QuickRenderText(text, Point{0, eventY - textSize.Y}, msgSize, getThemeColor(GUIFontColor), AlignLeft)
+
+			eventY -= textSize.Y
+
+		}
+
 		for _, event := range globals.EventLog.Events {
 
 			bgColor := getThemeColor(GUIMenuColor)
@@ -692,6 +730,8 @@ func main() {
 
 	log.Println("MasterPlan exited successfully.")
 
+	globals.RPCServer.Stop()
+
 	globals.Project.Destroy()
 
 	globals.Resources.Destroy()
@@ -789,7 +829,7 @@ func ConstructMenus() {
 
 	// File Menu
 
-	fileMenu := globals.MenuSystem.Add(NewMenu(&sdl.FRect{0, 48, 300, 350}, MenuCloseClickOut), "file", false)
+	fileMenu := globals.MenuSystem.Add(NewMenu(&sdl.FRect{0, 48, 300, 390}, MenuCloseClickOut), "file", false)
 	root = fileMenu.Pages["root"]
 
 	root.AddRow(AlignCenter).Add("New Project", NewButton("New Project", nil, nil, false, func() {
@@ -834,6 +874,10 @@ func ConstructMenus() {
 
 	}))
 	root.AddRow(AlignCenter).Add("Save Project As...", NewButton("Save Project As...", &sdl.FRect{0, 0, 256, 32}, nil, false, func() { globals.Project.SaveAs() }))
+	root.AddRow(AlignCenter).Add("Discard Undo History", NewButton("Discard Undo History", &sdl.FRect{0, 0, 256, 32}, nil, false, func() {
+		globals.Project.DiscardUndoHistory()
+		fileMenu.Close()
+	}))
 	root.AddRow(AlignCenter).Add("Settings", NewButton("Settings", nil, nil, false, func() {
 		settings := globals.MenuSystem.Get("settings")
 		settings.Center()
@@ -852,7 +896,7 @@ func ConstructMenus() {
 
 	// View Menu
 
-	viewMenu := globals.MenuSystem.Add(NewMenu(&sdl.FRect{48, 48, 300, 200}, MenuCloseClickOut), "view", false)
+	viewMenu := globals.MenuSystem.Add(NewMenu(&sdl.FRect{48, 48, 300, 416}, MenuCloseClickOut), "view", false)
 	root = viewMenu.Pages["root"]
 
 	root.AddRow(AlignCenter).Add("Create Menu", NewButton("Create", nil, nil, false, func() {
@@ -875,6 +919,97 @@ func ConstructMenus() {
 		viewMenu.Close()
 	}))
 
+	root.AddRow(AlignCenter).Add("Reminders", NewButton("Reminders", nil, nil, false, func() {
+		globals.MenuSystem.Get("reminders").Open()
+		viewMenu.Close()
+	}))
+
+	root.AddRow(AlignCenter).Add("Time Report", NewButton("Time Report", nil, nil, false, func() {
+		globals.MenuSystem.Get("time report").Open()
+		viewMenu.Close()
+	}))
+
+	root.AddRow(AlignCenter).Add("Tag Filter", NewButton("Tag Filter", nil, nil, false, func() {
+		globals.MenuSystem.Get("tag filter").Open()
+		viewMenu.Close()
+	}))
+
+	root.AddRow(AlignCenter).Add("Scripts", NewButton("Scripts", nil, nil, false, func() {
+		globals.MenuSystem.Get("scripts").Open()
+		viewMenu.Close()
+	}))
+
+	root.AddRow(AlignCenter).Add("Undo History", NewButton("Undo History", nil, nil, false, func() {
+		globals.MenuSystem.Get("undo history").Open()
+		viewMenu.Close()
+	}))
+
+	root.AddRow(AlignCenter).Add("Checkpoints", NewButton("Checkpoints", nil, nil, false, func() {
+		globals.MenuSystem.Get("checkpoints").Open()
+		viewMenu.Close()
+	}))
+
+	scriptsMenu := globals.MenuSystem.Add(NewMenu(&sdl.FRect{globals.ScreenSize.X/2 - (512 / 2), 96, 512, 128}, MenuCloseButton), "scripts", false)
+	scriptsMenu.Draggable = true
+	scriptsMenu.OnOpen = func() {
+
+		root = scriptsMenu.Pages["root"]
+		root.Destroy()
+
+		engine := globals.Project.Scripts
+
+		row = root.AddRow(AlignCenter)
+		row.Add("scripts label", NewLabel("Scripts", nil, false, AlignCenter))
+
+		if engine.Directory() == "" {
+			row = root.AddRow(AlignCenter)
+			row.Add("unsaved", NewLabel("Save the project to use scripts.", nil, false, AlignCenter))
+		} else if scripts := engine.Scripts(); len(scripts) == 0 {
+
+			row = root.AddRow(AlignCenter)
+			row.Add("no scripts", NewLabel("No scripts in the project's \""+ScriptFolder+"\" folder.", nil, false, AlignCenter))
+
+			row = root.AddRow(AlignCenter)
+			row.Add("create folder", NewButton("Create Scripts Folder", nil, nil, false, func() {
+				if err := engine.CreateDirectory(); err != nil {
+					globals.EventLog.Log("Error: Couldn't create scripts folder: %s", err.Error())
+				} else {
+					globals.EventLog.Log("Scripts folder created at [%s].", engine.Directory())
+				}
+				scriptsMenu.Close()
+			}))
+
+		} else {
+
+			for _, s := range scripts {
+				scriptPath := s
+				row = root.AddRow(AlignLeft)
+				row.Add("", NewButton("Run "+filepath.Base(scriptPath), nil, nil, false, func() {
+					engine.Run(scriptPath)
+				}))
+			}
+
+			row = root.AddRow(AlignCenter)
+			row.Add("stop handlers", NewButton("Stop All Script Handlers", nil, nil, false, func() {
+				engine.RemoveHandlers("")
+				globals.EventLog.Log("Removed all script message handlers.")
+			}))
+
+		}
+
+		row = root.AddRow(AlignCenter)
+		row.Add("open folder", NewButton("Open Scripts Folder", nil, nil, false, func() {
+			if engine.Directory() != "" {
+				browser.OpenFile(engine.Directory())
+			}
+		}))
+
+		idealSize := root.IdealSize()
+		rect := scriptsMenu.Rectangle()
+		scriptsMenu.Recreate(rect.W, idealSize.Y+16)
+
+	}
+
 	loadRecent := globals.MenuSystem.Add(NewMenu(&sdl.FRect{128, 96, 512, 128}, MenuCloseClickOut), "load recent", false)
 	loadRecent.OnOpen = func() {
 
@@ -967,6 +1102,11 @@ func ConstructMenus() {
 		card.SetCenter(globals.Project.Camera.TargetPosition)
 	}))
 
+	root.AddRow(AlignCenter).Add("create new frame", NewButton("Frame", nil, nil, false, func() {
+		card := globals.Project.CurrentPage.CreateNewCard(ContentTypeFrame)
+		card.SetCenter(globals.Project.Camera.TargetPosition)
+	}))
+
 	createMenu.Recreate(createMenu.Pages["root"].IdealSize().X+48, createMenu.Pages["root"].IdealSize().Y+16)
 
 	// Edit Menu
@@ -985,6 +1125,15 @@ func ConstructMenus() {
 	root.AddRow(AlignCenter).Add("set type", NewButton("Set Type", nil, nil, false, func() {
 		editMenu.SetPage("set type")
 	}))
+	root.AddRow(AlignCenter).Add("set tags", NewButton("Set Tags", nil, nil, false, func() {
+		editMenu.SetPage("set tags")
+	}))
+	root.AddRow(AlignCenter).Add("set recurrence", NewButton("Set Recurrence", nil, nil, false, func() {
+		editMenu.SetPage("set recurrence")
+	}))
+	root.AddRow(AlignCenter).Add("set reminder", NewButton("Set Reminder", nil, nil, false, func() {
+		editMenu.SetPage("set reminder")
+	}))
 
 	setColor := editMenu.AddPage("set color")
 	setColor.AddRow(AlignCenter).Add("label", NewLabel("Set Color", nil, false, AlignCenter))
@@ -1095,6 +1244,148 @@ func ConstructMenus() {
 		}
 	}))
 
+	setType.AddRow(AlignCenter).Add("set frame content type", NewButton("Frame", nil, nil, false, func() {
+		for _, card := range globals.Project.CurrentPage.Selection.AsSlice() {
+			card.SetContents(ContentTypeFrame)
+		}
+	}))
+
+	setTags := editMenu.AddPage("set tags")
+	setTags.AddRow(AlignCenter).Add("label", NewLabel("Set Tags", &sdl.FRect{0, 0, 192, 32}, false, AlignCenter))
+	setTags.AddRow(AlignCenter).Add("hint", NewLabel("Separate tags with commas.", nil, false, AlignCenter))
+
+	tagsText := NewLabel("", &sdl.FRect{0, 0, 256, 32}, false, AlignLeft)
+	tagsText.Editable = true
+	tagsText.RegexString = RegexNoNewlines
+	setTags.AddRow(AlignCenter).Add("tags text", tagsText)
+
+	setTags.OnOpen = func() {
+		tags := []string{}
+		for _, card := range globals.Project.CurrentPage.Selection.AsSlice() {
+			tags = append(tags, card.Tags()...)
+		}
+		tagsText.SetText([]rune(strings.Join(ParseTags(strings.Join(tags, ",")), ", ")))
+	}
+
+	setTags.AddRow(AlignCenter).Add("apply", NewButton("Apply to Selected", nil, nil, false, func() {
+		selectedCards := globals.Project.CurrentPage.Selection.Cards
+		for card := range selectedCards {
+			card.SetTags(tagsText.TextAsString())
+		}
+		globals.EventLog.Log("Tags applied for %d card(s).", len(selectedCards))
+	}))
+
+	setTags.AddRow(AlignCenter).Add("clear", NewButton("Clear Tags", nil, nil, false, func() {
+		selectedCards := globals.Project.CurrentPage.Selection.Cards
+		for card := range selectedCards {
+			card.SetTags()
+		}
+		tagsText.SetText([]rune(""))
+		globals.EventLog.Log("Tags cleared for %d card(s).", len(selectedCards))
+	}))
+
+	setRecurrence := editMenu.AddPage("set recurrence")
+	setRecurrence.AddRow(AlignCenter).Add("label", NewLabel("Set Recurrence", &sdl.FRect{0, 0, 256, 32}, false, AlignCenter))
+	setRecurrence.AddRow(AlignCenter).Add("hint", NewLabel("Daily, weekdays, weekly on mon,\nevery 3 days, or monthly.", nil, false, AlignCenter))
+
+	recurrenceText := NewLabel("", &sdl.FRect{0, 0, 256, 32}, false, AlignLeft)
+	recurrenceText.Editable = true
+	recurrenceText.RegexString = RegexNoNewlines
+	setRecurrence.AddRow(AlignCenter).Add("recurrence text", recurrenceText)
+
+	setRecurrence.OnOpen = func() {
+		recurrenceText.SetText([]rune(""))
+		for _, card := range globals.Project.CurrentPage.Selection.AsSlice() {
+			if recurrence := card.Recurrence(); recurrence != nil {
+				recurrenceText.SetText([]rune(recurrence.String()))
+				break
+			}
+		}
+	}
+
+	setRecurrence.AddRow(AlignCenter).Add("apply", NewButton("Apply to Selected", nil, nil, false, func() {
+
+		recurrence, ok := ParseRecurrence(recurrenceText.TextAsString())
+		if !ok {
+			globals.EventLog.Log("Error: Couldn't read recurrence rule [%s].", recurrenceText.TextAsString())
+			return
+		}
+
+		count := 0
+		for card := range globals.Project.CurrentPage.Selection.Cards {
+			// Only tasks can recur.
+			if card.Numberable() {
+				card.SetRecurrence(recurrence)
+				count++
+			}
+		}
+
+		recurrenceText.SetText([]rune(recurrence.String()))
+		globals.EventLog.Log("Recurrence set to %s for %d card(s).", recurrence.String(), count)
+
+	}))
+
+	setRecurrence.AddRow(AlignCenter).Add("clear", NewButton("Stop Recurring", nil, nil, false, func() {
+		count := 0
+		for card := range globals.Project.CurrentPage.Selection.Cards {
+			if card.Recurrence() != nil {
+				card.SetRecurrence(nil)
+				count++
+			}
+		}
+		recurrenceText.SetText([]rune(""))
+		globals.EventLog.Log("Recurrence cleared for %d card(s).", count)
+	}))
+
+	setReminder := editMenu.AddPage("set reminder")
+	setReminder.AddRow(AlignCenter).Add("label", NewLabel("Set Reminder", &sdl.FRect{0, 0, 256, 32}, false, AlignCenter))
+	setReminder.AddRow(AlignCenter).Add("hint", NewLabel("Remind at a date and time,\nwritten like YYYY-MM-DD HH:MM.", nil, false, AlignCenter))
+
+	reminderText := NewLabel("", &sdl.FRect{0, 0, 256, 32}, false, AlignLeft)
+	reminderText.Editable = true
+	reminderText.RegexString = RegexNoNewlines
+	setReminder.AddRow(AlignCenter).Add("reminder text", reminderText)
+
+	setReminder.OnOpen = func() {
+		// Default to an hour from now.
+		reminderText.SetText([]rune(time.Now().Add(time.Hour).Format(DeadlineFormat)))
+		for _, card := range globals.Project.CurrentPage.Selection.AsSlice() {
+			if t, ok := card.ReminderTime(); ok {
+				reminderText.SetText([]rune(t.Format(DeadlineFormat)))
+				break
+			}
+		}
+	}
+
+	setReminder.AddRow(AlignCenter).Add("apply", NewButton("Apply to Selected", nil, nil, false, func() {
+
+		t, ok := ParseDeadline(reminderText.TextAsString())
+		if !ok {
+			globals.EventLog.Log("Error: Couldn't read reminder time [%s]; it should be written like %s.", reminderText.TextAsString(), DeadlineFormat)
+			return
+		}
+
+		selectedCards := globals.Project.CurrentPage.Selection.Cards
+		for card := range selectedCards {
+			card.SetReminder(t)
+		}
+
+		reminderText.SetText([]rune(t.Format(DeadlineFormat)))
+		globals.EventLog.Log("Reminder set for %s for %d card(s).", t.Format(TimestampDisplayFormat), len(selectedCards))
+
+	}))
+
+	setReminder.AddRow(AlignCenter).Add("clear", NewButton("Clear Reminder", nil, nil, false, func() {
+		count := 0
+		for card := range globals.Project.CurrentPage.Selection.Cards {
+			if _, ok := card.ReminderTime(); ok {
+				card.ClearReminder()
+				count++
+			}
+		}
+		globals.EventLog.Log("Reminders cleared for %d card(s).", count)
+	}))
+
 	// Context Menu
 
 	contextMenu := globals.MenuSystem.Add(NewMenu(&sdl.FRect{0, 0, 256, 256}, MenuCloseClickOut), "context", false)
@@ -1191,6 +1482,107 @@ func ConstructMenus() {
 	row.Add("no", NewButton("No", &sdl.FRect{0, 0, 128, 32}, nil, false, func() { confirmLoad.Close() }))
 	confirmLoad.Recreate(root.IdealSize().X+48, root.IdealSize().Y+16)
 
+	confirmOverwrite := globals.MenuSystem.Add(NewMenu(&sdl.FRect{0, 0, 32, 32}, MenuCloseButton), "confirm overwrite", true)
+	confirmOverwrite.Draggable = true
+	root = confirmOverwrite.Pages["root"]
+	root.AddRow(AlignCenter).Add("label", NewLabel("The project file was changed on disk since it was loaded.", nil, false, AlignCenter))
+	root.AddRow(AlignCenter).Add("label-2", NewLabel("Overwrite it anyway?", nil, false, AlignCenter))
+	row = root.AddRow(AlignCenter)
+	row.Add("yes", NewButton("Overwrite", &sdl.FRect{0, 0, 128, 32}, nil, false, func() {
+		globals.Project.FileModTime = time.Time{}
+		globals.Project.Save()
+		confirmOverwrite.Close()
+	}))
+	row.Add("no", NewButton("Cancel", &sdl.FRect{0, 0, 128, 32}, nil, false, func() { confirmOverwrite.Close() }))
+	confirmOverwrite.Recreate(root.IdealSize().X+48, root.IdealSize().Y+16)
+
+	changedOnDisk := globals.MenuSystem.Add(NewMenu(&sdl.FRect{0, 0, 32, 32}, MenuCloseButton), "project changed on disk", true)
+	changedOnDisk.Draggable = true
+	root = changedOnDisk.Pages["root"]
+	root.AddRow(AlignCenter).Add("label", NewLabel("The project file was changed outside of MasterPlan.", nil, false, AlignCenter))
+	root.AddRow(AlignCenter).Add("label-2", NewLabel("What should happen to your unsaved changes?", nil, false, AlignCenter))
+	row = root.AddRow(AlignCenter)
+	row.Add("keep", NewButton("Keep Mine", &sdl.FRect{0, 0, 160, 32}, nil, false, func() {
+		globals.Project.KeepLocalChanges()
+		changedOnDisk.Close()
+	}))
+	row.Add("take", NewButton("Take Theirs", &sdl.FRect{0, 0, 160, 32}, nil, false, func() {
+		globals.Project.Reload()
+		changedOnDisk.Close()
+	}))
+	row.Add("merge", NewButton("Merge", &sdl.FRect{0, 0, 160, 32}, nil, false, func() {
+		globals.Project.MergeFromDisk()
+		changedOnDisk.Close()
+	}))
+	changedOnDisk.Recreate(root.IdealSize().X+48, root.IdealSize().Y+16)
+
+	mergeConflicts := globals.MenuSystem.Add(NewMenu(&sdl.FRect{0, 0, 640, 400}, MenuCloseButton), "merge conflicts", false)
+	mergeConflicts.Draggable = true
+	mergeConflicts.Resizeable = true
+
+	var conflictsShown *Project
+
+	mergeConflicts.OnOpen = func() {
+		conflictsShown = nil
+	}
+
+	root = mergeConflicts.Pages["root"]
+
+	// As with the reminders menu, the list is rebuilt here rather than from its own buttons.
+	root.OnUpdate = func() {
+
+		project := globals.Project
+
+		if project == conflictsShown && !project.MergeConflictsChanged {
+			return
+		}
+
+		conflictsShown = project
+		project.MergeConflictsChanged = false
+
+		conflictsRoot := mergeConflicts.Pages["root"]
+		conflictsRoot.Destroy()
+
+		conflictsRoot.AddRow(AlignCenter).Add("header", NewLabel("Merge Conflicts", nil, false, AlignCenter))
+
+		if len(project.MergeConflicts) == 0 {
+			conflictsRoot.AddRow(AlignCenter).Add("none", NewLabel("No conflicts left to resolve.", nil, false, AlignCenter))
+			return
+		}
+
+		conflictsRoot.AddRow(AlignCenter).Add("", NewLabel("These Cards were changed both here and on disk.", nil, false, AlignCenter))
+
+		row := conflictsRoot.AddRow(AlignCenter)
+		row.Add("", NewButton("Keep All Mine", nil, nil, false, func() {
+			for _, conflict := range append([]*MergeConflict{}, project.MergeConflicts...) {
+				project.ResolveConflict(conflict, false)
+			}
+		}))
+		row.Add("", NewButton("Take All Theirs", nil, nil, false, func() {
+			for _, conflict := range append([]*MergeConflict{}, project.MergeConflicts...) {
+				project.ResolveConflict(conflict, true)
+			}
+		}))
+
+		for _, c := range project.MergeConflicts {
+
+			conflict := c
+
+			row := conflictsRoot.AddRow(AlignLeft)
+			row.Add("", NewLabel(conflict.Description(), nil, false, AlignLeft))
+
+			row = conflictsRoot.AddRow(AlignRight)
+			row.Add("", NewButton("Keep Mine", nil, nil, false, func() {
+				project.ResolveConflict(conflict, false)
+			}))
+			row.Add("", NewButton("Take Theirs", nil, nil, false, func() {
+				project.ResolveConflict(conflict, true)
+			}))
+
+		}
+
+	}
+
 	// // Confirm Load Menu - do this after Project.Modified works again.
 
 	// confirmQuit := globals.MenuSystem.Add(NewMenu(&sdl.FRect{0, 0, 32, 32}, true), "confirm quit", true)
@@ -1254,6 +1646,10 @@ func ConstructMenus() {
 	row.Add("", NewLabel("Timers Play Alarm Sound:", nil, false, AlignCenter))
 	row.Add("", NewCheckbox(0, 0, false, globals.Settings.Get(SettingsPlayAlarmSound)))
 
+	row = sound.AddRow(AlignCenter)
+	row.Add("", NewLabel("Reminders Play Alarm Sound:", nil, false, AlignCenter))
+	row.Add("", NewCheckbox(0, 0, false, globals.Settings.Get(SettingsRemindersPlayAlarmSound)))
+
 	row = sound.AddRow(AlignCenter)
 	row.Add("", NewLabel("Audio Volume:", nil, false, AlignCenter))
 	number := NewNumberSpinner(&sdl.FRect{0, 0, 256, 32}, false, globals.Settings.Get(SettingsAudioVolume))
@@ -1286,6 +1682,10 @@ func ConstructMenus() {
 	row.Add("", NewLabel("Notify on Elapsed Timers:", nil, false, AlignLeft))
 	row.Add("", NewCheckbox(0, 0, false, globals.Settings.Get(SettingsNotifyOnElapsedTimers)))
 
+	row = general.AddRow(AlignCenter)
+	row.Add("", NewLabel("Notify on Reminders:", nil, false, AlignLeft))
+	row.Add("", NewCheckbox(0, 0, false, globals.Settings.Get(SettingsNotifyOnReminders)))
+
 	row = general.AddRow(AlignCenter)
 	row.Add("", NewLabel("Show About Dialog On Start:", nil, false, AlignLeft))
 	row.Add("", NewCheckbox(0, 0, false, globals.Settings.Get(SettingsShowAboutDialogOnStart)))
@@ -1302,6 +1702,62 @@ func ConstructMenus() {
 	screenshotPath.Property = globals.Settings.Get(SettingsScreenshotPath)
 	row.Add("", screenshotPath)
 
+	row = general.AddRow(AlignCenter)
+	row.Add("", NewLabel("Save Undo History With Project:", nil, false, AlignLeft))
+	row.Add("", NewCheckbox(0, 0, false, globals.Settings.Get(SettingsPersistentUndoHistory)))
+
+	row = general.AddRow(AlignCenter)
+	row.Add("", NewLabel("Max Saved Undo Steps:", nil, false, AlignLeft))
+	undoCap := NewNumberSpinner(&sdl.FRect{0, 0, 256, 32}, false, globals.Settings.Get(SettingsUndoHistoryFrameCap))
+	undoCap.MinValue = 1
+	row.Add("", undoCap)
+
+	row = general.AddRow(AlignCenter)
+	row.Add("", NewLabel("Undo Memory Budget (MB, 0 = No Limit):", nil, false, AlignLeft))
+	undoBudget := NewNumberSpinner(&sdl.FRect{0, 0, 256, 32}, false, globals.Settings.Get(SettingsUndoMemoryBudget))
+	undoBudget.MinValue = 0
+	row.Add("", undoBudget)
+
+	row = general.AddRow(AlignCenter)
+	row.Add("", NewLabel("Automation Server:", nil, false, AlignLeft))
+	row.Add("", NewCheckbox(0, 0, false, globals.Settings.Get(SettingsAutomationServer)))
+
+	row = general.AddRow(AlignCenter)
+	row.Add("", NewLabel("Automation Server Address:", nil, false, AlignLeft))
+	rpcAddress := NewLabel("Automation server address", nil, false, AlignLeft)
+	rpcAddress.Editable = true
+	rpcAddress.RegexString = RegexNoNewlines
+	rpcAddress.Property = globals.Settings.Get(SettingsAutomationServerAddress)
+	rpcAddress.OnClickOut = func() {
+		rpcAddress.Property.Set(rpcAddress.TextAsString())
+		if globals.RPCServer.Running() && globals.RPCServer.Address != rpcAddress.TextAsString() {
+			globals.RPCServer.Start()
+		}
+	}
+	row.Add("", rpcAddress)
+
+	row = general.AddRow(AlignCenter)
+	row.Add("", NewLabel("Automation Server Token:", nil, false, AlignLeft))
+	rpcToken := NewLabel("Automation server token", nil, false, AlignLeft)
+	rpcToken.Editable = true
+	rpcToken.RegexString = RegexNoNewlines
+	rpcToken.Property = globals.Settings.Get(SettingsAutomationServerToken)
+	rpcToken.OnClickOut = func() {
+		rpcToken.Property.Set(rpcToken.TextAsString())
+		if globals.RPCServer.Running() {
+			globals.RPCServer.Start()
+		}
+	}
+	row.Add("", rpcToken)
+
+	row = general.AddRow(AlignCenter)
+	row.Add("", NewButton("New Token", nil, nil, false, func() {
+		globals.Settings.Get(SettingsAutomationServerToken).Set(NewRPCToken())
+		if globals.RPCServer.Running() {
+			globals.RPCServer.Start()
+		}
+	}))
+
 	row = general.AddRow(AlignCenter)
 	row.Add("", NewButton("Browse", nil, nil, false, func() {
 
@@ -1421,6 +1877,24 @@ func ConstructMenus() {
 	heldKeys := []sdl.Keycode{}
 	heldButtons := []uint8{}
 
+	// Key combos released while rebinding are gathered up; if another combo follows within the chord timeout, the shortcut
+	// becomes a chord (e.g. "Ctrl+K, C").
+	recordedCombos := []KeyCombo{}
+	recordedTime := 0.0
+
+	finishRebinding := func() {
+		rebindingKey = nil
+		rebindingShortcut = nil
+		heldKeys = []sdl.Keycode{}
+		heldButtons = []uint8{}
+		recordedCombos = []KeyCombo{}
+		globals.Keybindings.Rebinding = false
+	}
+
+	settings.OnClose = finishRebinding
+
+	conflictsLabel := NewLabel("No keybinding clashes.", nil, false, AlignCenter)
+
 	input := settings.AddPage("input")
 	input.OnUpdate = func() {
 
@@ -1428,27 +1902,42 @@ func ConstructMenus() {
 
 		if rebindingKey != nil {
 
-			rebindingKey.Label.SetText([]rune("Rebinding..."))
+			globals.Keybindings.Rebinding = true
+
+			if len(recordedCombos) > 0 {
+				text := "Rebinding: "
+				for _, combo := range recordedCombos {
+					text += combo.String() + ", "
+				}
+				rebindingKey.Label.SetText([]rune(text + "..."))
+			} else {
+				rebindingKey.Label.SetText([]rune("Rebinding..."))
+			}
 
 			if globals.Keyboard.Key(sdl.K_ESCAPE).Pressed() {
-				rebindingKey = nil
-				rebindingShortcut = nil
+				finishRebinding()
 			} else {
 
-				if (len(globals.Keyboard.HeldKeys()) == 0 && len(heldKeys) > 0) || (len(globals.Mouse.HeldButtons()) == 0 && len(heldButtons) > 0) {
+				if len(globals.Mouse.HeldButtons()) == 0 && len(heldButtons) > 0 {
 
-					if len(heldButtons) > 0 {
-						rebindingShortcut.SetButton(heldButtons[0], heldKeys...)
-					} else if len(heldKeys) > 0 {
-						rebindingShortcut.SetKeys(heldKeys[len(heldKeys)-1], heldKeys[:len(heldKeys)-1]...)
-					}
+					rebindingShortcut.SetButton(heldButtons[0], heldKeys...)
 					globals.Keybindings.UpdateShortcutFamilies()
+					finishRebinding()
+					SaveSettings()
+
+				} else if len(globals.Keyboard.HeldKeys()) == 0 && len(heldKeys) > 0 {
 
-					rebindingKey = nil
-					rebindingShortcut = nil
+					recordedCombos = append(recordedCombos, KeyCombo{Key: heldKeys[len(heldKeys)-1], Modifiers: heldKeys[:len(heldKeys)-1]})
+					recordedTime = globals.Time
 					heldKeys = []sdl.Keycode{}
-					heldButtons = []uint8{}
 
+				} else if len(heldKeys) == 0 && len(recordedCombos) > 0 && globals.Time-recordedTime > ChordTimeout {
+
+					last := recordedCombos[len(recordedCombos)-1]
+					rebindingShortcut.SetKeys(last.Key, last.Modifiers...)
+					rebindingShortcut.SetChord(recordedCombos[:len(recordedCombos)-1]...)
+					globals.Keybindings.UpdateShortcutFamilies()
+					finishRebinding()
 					SaveSettings()
 
 				} else {
@@ -1475,10 +1964,24 @@ func ConstructMenus() {
 
 		} else {
 
+			conflictCount := 0
+
 			for name, shortcut := range globals.Keybindings.Shortcuts {
 				b := input.FindElement(name+"-b", false).(*Button)
 				b.Label.SetText([]rune(shortcut.KeysToString()))
 
+				c := input.FindElement(name+"-c", false).(*Label)
+				if conflicts := globals.Keybindings.Conflicts(name); len(conflicts) > 0 {
+					names := []string{}
+					for _, other := range conflicts {
+						names = append(names, other.Name)
+					}
+					c.SetText([]rune("Clashes with: " + strings.Join(names, ", ")))
+					conflictCount++
+				} else {
+					c.SetText([]rune(""))
+				}
+
 				d := input.FindElement(name+"-d", false).(*Button)
 				d.Disabled = shortcut.IsDefault()
 				if d.Disabled {
@@ -1488,6 +1991,12 @@ func ConstructMenus() {
 				}
 			}
 
+			if conflictCount > 0 {
+				conflictsLabel.SetText([]rune(fmt.Sprintf("%d keybindings clash with others.", conflictCount)))
+			} else {
+				conflictsLabel.SetText([]rune("No keybinding clashes."))
+			}
+
 		}
 
 	}
@@ -1543,38 +2052,113 @@ func ConstructMenus() {
 		}
 	}))
 
-	for _, s := range globals.Keybindings.ShortcutsInOrder {
+	row = input.AddRow(AlignCenter)
+	row.Add("export profile", NewButton("Export Profile", nil, nil, false, func() {
 
-		// Make a copy so the OnPressed() function below refers to "this" shortcut, rather than the last one in the range
-		shortcut := s
+		if filename, err := zenity.SelectFileSave(zenity.Title("Export Keybinding Profile..."), zenity.ConfirmOverwrite(), zenity.FileFilter{Name: "Keybinding Profile (*.json)", Patterns: []string{"*.json"}}); err == nil {
 
-		row = input.AddRow(AlignCenter)
+			if filepath.Ext(filename) != ".json" {
+				filename += ".json"
+			}
 
-		row.Add("key-"+shortcut.Name, NewLabel(shortcut.Name, nil, false, AlignLeft))
-		row.ExpandElements = true
+			if err := globals.Keybindings.ExportProfile(filename); err != nil {
+				globals.EventLog.Log("Error: Couldn't export keybinding profile: %s", err.Error())
+			} else {
+				globals.EventLog.Log("Keybinding profile exported to [%s].", filename)
+			}
 
-		redefineButton := NewButton(shortcut.KeysToString(), nil, nil, false, nil)
+		}
+
+	}))
+
+	row.Add("import profile", NewButton("Import Profile", nil, nil, false, func() {
+
+		if filename, err := zenity.SelectFile(zenity.Title("Import Keybinding Profile..."), zenity.FileFilter{Name: "Keybinding Profile (*.json)", Patterns: []string{"*.json"}}); err == nil {
+
+			if err := globals.Keybindings.ImportProfile(filename); err != nil {
+				globals.EventLog.Log("Error: Couldn't import keybinding profile: %s", err.Error())
+			} else {
+				globals.EventLog.Log("Keybinding profile [%s] imported.", filename)
+				SaveSettings()
+			}
 
-		redefineButton.OnPressed = func() {
-			rebindingKey = redefineButton
-			rebindingShortcut = shortcut
 		}
 
-		row.Add(shortcut.Name+"-b", redefineButton)
+	}))
 
-		button := NewButton("Reset to Default", nil, nil, false, nil)
+	row = input.AddRow(AlignCenter)
+	row.Add("preset label", NewLabel("Preset:", nil, false, AlignLeft))
 
-		button.OnPressed = func() {
-			shortcut.ResetToDefault()
-			globals.Keybindings.UpdateShortcutFamilies()
+	// The dropdown needs at least one option, even if the presets folder is missing.
+	listPresets := func() []string {
+		if presets := KeybindingPresets(); len(presets) > 0 {
+			return presets
 		}
+		return []string{"---"}
+	}
 
-		row.Add(shortcut.Name+"-d", button)
+	presets := listPresets()
+	presetDropdown := NewDropdown(&sdl.FRect{0, 0, 192, 32}, false, nil, presets...)
+	presetDropdown.OnOpen = func() {
+		presets = listPresets()
+		presetDropdown.SetOptions(presets...)
 	}
+	row.Add("preset dropdown", presetDropdown)
 
-	about := settings.AddPage("about")
+	row.Add("apply preset", NewButton("Apply", nil, nil, false, func() {
 
-	about.DefaultExpand = true
+		if presetDropdown.ChosenIndex < 0 || presetDropdown.ChosenIndex >= len(presets) {
+			return
+		}
+
+		preset := presets[presetDropdown.ChosenIndex]
+
+		if err := globals.Keybindings.ImportProfile(filepath.Join(LocalRelativePath(KBProfilePath), preset+".json")); err != nil {
+			globals.EventLog.Log("Error: Couldn't apply keybinding preset [%s]: %s", preset, err.Error())
+		} else {
+			globals.EventLog.Log("Keybinding preset [%s] applied.", preset)
+			SaveSettings()
+		}
+
+	}))
+
+	row = input.AddRow(AlignCenter)
+	row.Add("conflicts", conflictsLabel)
+
+	for _, s := range globals.Keybindings.ShortcutsInOrder {
+
+		// Make a copy so the OnPressed() function below refers to "this" shortcut, rather than the last one in the range
+		shortcut := s
+
+		row = input.AddRow(AlignCenter)
+
+		row.Add("key-"+shortcut.Name, NewLabel(shortcut.Name, nil, false, AlignLeft))
+		row.ExpandElements = true
+
+		redefineButton := NewButton(shortcut.KeysToString(), nil, nil, false, nil)
+
+		redefineButton.OnPressed = func() {
+			rebindingKey = redefineButton
+			rebindingShortcut = shortcut
+		}
+
+		row.Add(shortcut.Name+"-b", redefineButton)
+
+		button := NewButton("Reset to Default", nil, nil, false, nil)
+
+		button.OnPressed = func() {
+			shortcut.ResetToDefault()
+			globals.Keybindings.UpdateShortcutFamilies()
+		}
+
+		row.Add(shortcut.Name+"-d", button)
+
+		row.Add(shortcut.Name+"-c", NewLabel("", nil, false, AlignLeft))
+	}
+
+	about := settings.AddPage("about")
+
+	about.DefaultExpand = true
 
 	row = about.AddRow(AlignCenter)
 	row.Add("", NewLabel("About", nil, false, AlignCenter))
@@ -1597,7 +2181,7 @@ func ConstructMenus() {
 
 	// Search Menu
 
-	find := globals.MenuSystem.Add(NewMenu(&sdl.FRect{9999, 9999, 512, 96}, MenuCloseButton), "find", false)
+	find := globals.MenuSystem.Add(NewMenu(&sdl.FRect{9999, 9999, 512, 208}, MenuCloseButton), "find", false)
 	find.AnchorMode = MenuAnchorTopRight
 	find.Draggable = true
 	find.Resizeable = true
@@ -1613,26 +2197,101 @@ func ConstructMenus() {
 	foundCards := []*Card{}
 	foundIndex := 0
 
+	// Where in the current Card the next match to be replaced one by one is looked for from.
+	var replaceCard *Card
+	replacePropIndex := 0
+	replaceOffset := 0
+
 	caseSensitive := false
 
+	replaceLabel := NewLabel("", &sdl.FRect{0, 0, 256, 32}, false, AlignLeft)
+	replaceLabel.Editable = true
+	replaceLabel.RegexString = RegexNoNewlines
+
+	replaceFilepaths := NewCheckbox(0, 0, false, nil)
+	replacePreviewLabel := NewLabel("0 matches in 0 cards", &sdl.FRect{0, 0, 384, 32}, false, AlignCenter)
+
+	// searchPattern returns a regular expression matching the search text literally, respecting the case sensitivity setting.
+	searchPattern := func() *regexp.Regexp {
+		pattern := regexp.QuoteMeta(searchLabel.TextAsString())
+		if !caseSensitive {
+			pattern = "(?i)" + pattern
+		}
+		return regexp.MustCompile(pattern)
+	}
+
+	// replaceableProps returns the properties of the given Card that can be replaced in, always in the same order.
+	replaceableProps := func(card *Card) []*Property {
+		props := []*Property{}
+		for _, propName := range []string{"description", "filepath"} {
+			if propName == "filepath" && !replaceFilepaths.Checked {
+				continue
+			}
+			if prop, exists := card.Properties.Props[propName]; exists && prop.InUse && prop.IsString() {
+				props = append(props, prop)
+			}
+		}
+		return props
+	}
+
+	updateReplacePreview := func() {
+
+		matchCount := 0
+		cardCount := 0
+
+		if len(searchLabel.Text) > 0 {
+
+			pattern := searchPattern()
+
+			for _, page := range globals.Project.Pages {
+				for _, card := range page.Cards {
+					cardMatches := 0
+					for _, prop := range replaceableProps(card) {
+						cardMatches += len(pattern.FindAllStringIndex(prop.AsString(), -1))
+					}
+					if cardMatches > 0 {
+						matchCount += cardMatches
+						cardCount++
+					}
+				}
+			}
+
+		}
+
+		replacePreviewLabel.SetText([]rune(fmt.Sprintf("%d matches in %d cards", matchCount, cardCount)))
+
+	}
+
 	findFunc := func() {
 
 		foundCards = []*Card{}
 
+		updateReplacePreview()
+
 		if len(searchLabel.Text) == 0 {
 			foundLabel.SetText([]rune("0 of 0"))
 			return
 		}
 
+		// Searches like "completed this week" find Cards by their timestamps instead.
+		timestamp, start, end, timestampQuery := ParseTimestampQuery(searchLabel.TextAsString(), time.Now())
+
 		for _, page := range globals.Project.Pages {
 
 			page.Selection.Clear()
 
 			for _, card := range page.Cards {
 
+				if timestampQuery {
+					if card.MatchesTimestampQuery(timestamp, start, end) {
+						foundCards = append(foundCards, card)
+					}
+					continue
+				}
+
 				for propName, prop := range card.Properties.Props {
 
-					if (propName == "description" || propName == "filepath") && prop.InUse && prop.IsString() {
+					if (propName == "description" || propName == "filepath" || propName == "tags") && prop.InUse && prop.IsString() {
 
 						propString := prop.AsString()
 						searchString := searchLabel.TextAsString()
@@ -1644,7 +2303,7 @@ func ConstructMenus() {
 
 						if strings.Contains(propString, searchString) {
 							foundCards = append(foundCards, card)
-							continue
+							break
 						}
 					}
 
@@ -1672,8 +2331,168 @@ func ConstructMenus() {
 
 	}
 
+	// replaced captures the change to a Card that's had text replaced in it, relinking any resources that were pointed to by a
+	// changed filepath.
+	replaced := func(card *Card) {
+
+		switch contents := card.Contents.(type) {
+		case *ImageContents:
+			contents.LoadFile()
+		case *SoundContents:
+			contents.LoadFile()
+		}
+
+		card.captureChange()
+		card.CreateUndoState = false
+
+	}
+
+	// replaceIn replaces the search text in the given Cards, capturing an undo step for each Page they're on. Undoing switches
+	// to a step's Page before undoing it, so one step across Pages would take an extra undo to do anything.
+	replaceIn := func(cards ...*Card) {
+
+		if len(searchLabel.Text) == 0 {
+			return
+		}
+
+		pattern := searchPattern()
+		replacement := replaceLabel.TextAsString()
+		replacedCount := 0
+
+		pages := []*Page{}
+		pageCards := map[*Page][]*Card{}
+
+		for _, card := range cards {
+			if _, exists := pageCards[card.Page]; !exists {
+				pages = append(pages, card.Page)
+			}
+			pageCards[card.Page] = append(pageCards[card.Page], card)
+		}
+
+		// The current Page's step goes last, so it's the first one undone.
+		sort.SliceStable(pages, func(i, j int) bool { return !pages[i].IsCurrent() && pages[j].IsCurrent() })
+
+		for _, page := range pages {
+
+			for _, card := range pageCards[page] {
+
+				changed := false
+
+				for _, prop := range replaceableProps(card) {
+					if text := prop.AsString(); pattern.MatchString(text) {
+						replacedCount += len(pattern.FindAllStringIndex(text, -1))
+						prop.Set(pattern.ReplaceAllLiteralString(text, replacement))
+						changed = true
+					}
+				}
+
+				if changed {
+					replaced(card)
+				}
+
+			}
+
+			globals.Project.UndoHistory.Update()
+
+		}
+
+		if replacedCount > 0 {
+			globals.EventLog.Log("Replaced %d instance(s) of \"%s\" with \"%s\".", replacedCount, searchLabel.TextAsString(), replacement)
+		}
+
+		findFunc()
+
+	}
+
+	// replaceNext replaces the current match only, then moves on to the next one; once there are no more matches left in the
+	// current Card, it moves on to the next Card.
+	replaceNext := func() {
+
+		if len(searchLabel.Text) == 0 || len(foundCards) == 0 {
+			return
+		}
+
+		pattern := searchPattern()
+		replacement := replaceLabel.TextAsString()
+
+		// Cards can be found by their tags or timestamps, so the current one might not have anything to replace.
+		for attempts := 0; attempts < len(foundCards); attempts++ {
+
+			card := foundCards[foundIndex]
+
+			if card != replaceCard {
+				replaceCard = card
+				replacePropIndex = 0
+				replaceOffset = 0
+			}
+
+			props := replaceableProps(card)
+
+			for ; replacePropIndex < len(props); replacePropIndex, replaceOffset = replacePropIndex+1, 0 {
+
+				prop := props[replacePropIndex]
+				text := prop.AsString()
+
+				if replaceOffset > len(text) {
+					continue
+				}
+
+				match := pattern.FindStringIndex(text[replaceOffset:])
+				if match == nil {
+					continue
+				}
+
+				start, end := replaceOffset+match[0], replaceOffset+match[1]
+				prop.Set(text[:start] + replacement + text[end:])
+				replaceOffset = start + len(replacement)
+
+				replaced(card)
+
+				globals.EventLog.Log("Replaced \"%s\" with \"%s\".", text[start:end], replacement)
+
+				// Stay on this Card if it's got more to replace; otherwise, go on to the next one.
+				remaining := false
+				for i, p := range props[replacePropIndex:] {
+					from := 0
+					if i == 0 {
+						from = replaceOffset
+					}
+					if from <= len(p.AsString()) && pattern.MatchString(p.AsString()[from:]) {
+						remaining = true
+						break
+					}
+				}
+
+				next := foundCards[(foundIndex+1)%len(foundCards)]
+
+				findFunc()
+
+				if !remaining {
+					foundIndex = 0
+					for i, c := range foundCards {
+						if c == next {
+							foundIndex = i
+							break
+						}
+					}
+					findFunc()
+				}
+
+				return
+
+			}
+
+			foundIndex = (foundIndex + 1) % len(foundCards)
+
+		}
+
+		findFunc()
+
+	}
+
 	searchLabel.OnChange = func() {
 		foundIndex = 0
+		replaceCard = nil
 		findFunc()
 	}
 
@@ -1681,11 +2500,13 @@ func ConstructMenus() {
 
 		if globals.Keybindings.Pressed(KBFindNext) {
 			foundIndex++
+			replaceCard = nil
 			findFunc()
 			searchLabel.Editing = true
 			searchLabel.Selection.SelectAll()
 		} else if globals.Keybindings.Pressed(KBFindPrev) {
 			foundIndex--
+			replaceCard = nil
 			findFunc()
 			searchLabel.Editing = true
 			searchLabel.Selection.SelectAll()
@@ -1720,6 +2541,7 @@ func ConstructMenus() {
 
 	prev := NewIconButton(0, 0, &sdl.Rect{112, 32, 32, 32}, false, func() {
 		foundIndex--
+		replaceCard = nil
 		findFunc()
 	})
 	prev.Flip = sdl.FLIP_HORIZONTAL
@@ -1729,121 +2551,952 @@ func ConstructMenus() {
 
 	row.Add("", NewIconButton(0, 0, &sdl.Rect{112, 32, 32, 32}, false, func() {
 		foundIndex++
+		replaceCard = nil
 		findFunc()
 	}))
 
-	// Previous sub-page menu
+	row = root.AddRow(AlignCenter)
+	row.Add("", NewLabel("Replace:", nil, false, AlignCenter))
+	row.Add("", NewIconButton(0, 0, &sdl.Rect{176, 96, 32, 32}, false, func() {
+		replaceLabel.SetText([]rune(""))
+	}))
+	row.Add("", replaceLabel)
 
-	prevSubPageMenu := globals.MenuSystem.Add(NewMenu(&sdl.FRect{0, 0, 512, 96}, MenuCloseNone), "prev sub page", false)
-	prevSubPageMenu.Opened = false
-	prevSubPageMenu.Center()
-	prevSubPageMenu.Draggable = true
+	row = root.AddRow(AlignCenter)
+	row.Add("", NewButton("Replace", nil, nil, false, func() {
+		replaceNext()
+	}))
+	row.Add("", NewButton("Replace All", nil, nil, false, func() {
+		replaceIn(foundCards...)
+	}))
+	row.Add("", NewLabel("File Paths:", nil, false, AlignCenter))
+	replaceFilepaths.OnPressed = func() {
+		replaceFilepaths.Checked = !replaceFilepaths.Checked
+		updateReplacePreview()
+	}
+	row.Add("", replaceFilepaths)
 
-	row = prevSubPageMenu.Pages["root"].AddRow(AlignCenter)
-	subName := NewLabel("sub page name", nil, false, AlignCenter)
-	row.Add("name", subName)
+	row = root.AddRow(AlignCenter)
+	row.Add("", replacePreviewLabel)
+
+	// Undo History
+
+	undoHistoryMenu := globals.MenuSystem.Add(NewMenu(&sdl.FRect{globals.ScreenSize.X - 480, 48, 448, 480}, MenuCloseButton), "undo history", false)
+	undoHistoryMenu.Draggable = true
+	undoHistoryMenu.Resizeable = true
+
+	root = undoHistoryMenu.Pages["root"]
+
+	var undoHistoryShown *UndoHistory
+	undoHistoryRevision := -1
 
-	root = prevSubPageMenu.Pages["root"]
 	root.OnUpdate = func() {
-		subName.SetText([]rune("Sub-Page: " + globals.Project.CurrentPage.Name))
-		subName.SetMaxSize(512, subName.RendererResult.TextSize.Y)
-		prevSubPageMenu.Recreate(512, prevSubPageMenu.Rect.H)
-	}
 
-	row = prevSubPageMenu.Pages["root"].AddRow(AlignCenter)
-	row.Add("go up", NewButton("Go Up", nil, nil, false, func() {
-		globals.Project.GoUpFromSubpage()
-	}))
-	// Stats Menu
+		history := globals.Project.UndoHistory
 
-	stats := globals.MenuSystem.Add(NewMenu(&sdl.FRect{globals.ScreenSize.X/2 - (700 / 2), 9999, 700, 274}, MenuCloseButton), "stats", false)
-	stats.Draggable = true
-	stats.Resizeable = true
-	stats.AnchorMode = MenuAnchorBottom
+		if history == undoHistoryShown && history.Revision == undoHistoryRevision {
+			return
+		}
 
-	root = stats.Pages["root"]
+		undoHistoryShown = history
+		undoHistoryRevision = history.Revision
 
-	row = root.AddRow(AlignCenter)
-	row.Add("", NewLabel("Stats", nil, false, AlignCenter))
+		historyRoot := undoHistoryMenu.Pages["root"]
+		historyRoot.Destroy()
 
-	row = root.AddRow(AlignLeft)
-	maxLabel := NewLabel("so many cards existing", nil, false, AlignLeft)
-	row.Add("", maxLabel)
+		historyRoot.AddRow(AlignCenter).Add("header", NewLabel("Undo History", nil, false, AlignCenter))
+		historyRoot.AddRow(AlignCenter).Add("memory", NewLabel(fmt.Sprintf("%d steps, %.2f MB", len(history.Frames), float64(history.MemoryUsage())/1024/1024), nil, false, AlignCenter))
 
-	row = root.AddRow(AlignLeft)
-	completedLabel := NewLabel("so many cards completed", nil, false, AlignLeft)
-	row.Add("", completedLabel)
-	row.ExpandElements = true
+		current := history.CurrentState()
 
-	row = root.AddRow(AlignLeft)
-	row.Add("", NewSpacer(&sdl.FRect{0, 0, 32, 1}))
+		for _, e := range history.Tree() {
 
-	row = root.AddRow(AlignLeft)
-	row.Add("time estimation", NewLabel("Time Estimation:", nil, false, AlignLeft))
+			entry := e
 
-	timeNumber := NewLabel("15", &sdl.FRect{0, 0, 128, 32}, false, AlignCenter)
-	timeNumber.Editable = true
-	timeNumber.RegexString = RegexOnlyDigits
-	row.Add("", timeNumber)
+			text := strings.Repeat("   ", entry.Depth) + entry.Frame.Time.Format("15:04") + "  " + entry.Frame.Label
+			if entry.Frame == current {
+				text = "> " + text
+			}
+
+			row := historyRoot.AddRow(AlignLeft)
+			row.Add("", NewButton(text, nil, nil, false, func() {
+				history.JumpTo(entry.Frame)
+			}))
+
+		}
 
-	timeUnitChoices := []string{
-		"Minutes",
-		"Hours",
-		"Days",
-		"Weeks",
-		"Months",
 	}
 
-	row = root.AddRow(AlignLeft)
-	row.ExpandElements = true
-	timeUnit := NewButtonGroup(&sdl.FRect{0, 0, 32, 32}, false, func(index int) {}, globals.Settings.Get("time unit"), timeUnitChoices...)
+	// Checkpoints
 
-	// timeUnit := NewDropdown(nil, false, func(index int) {}, timeUnitChoices...)
-	row.Add("", timeUnit)
+	checkpointsMenu := globals.MenuSystem.Add(NewMenu(&sdl.FRect{globals.ScreenSize.X/2 - (560 / 2), 48, 560, 480}, MenuCloseButton), "checkpoints", false)
+	checkpointsMenu.Draggable = true
+	checkpointsMenu.Resizeable = true
 
-	row = root.AddRow(AlignLeft)
-	estimatedTime := NewLabel("Time estimation label", nil, false, AlignLeft)
-	row.Add("", estimatedTime)
-	row.ExpandElements = true
+	checkpointName := NewLabel("Checkpoint", &sdl.FRect{0, 0, 320, 32}, false, AlignLeft)
+	checkpointName.Editable = true
+	checkpointName.RegexString = RegexNoNewlines
 
-	row = root.AddRow(AlignLeft)
-	row.Add("", NewLabel("Limit time estimation read-out to same as units: ", nil, false, AlignLeft))
+	var checkpointsShown *Project
+	var comparison *CheckpointComparison
+	checkpointsChanged := true
 
-	limitTimeCheckbox := NewCheckbox(0, 0, false, nil)
-	row.Add("", limitTimeCheckbox)
+	checkpointsMenu.OnOpen = func() {
+		checkpointName.SetText([]rune("Checkpoint: " + time.Now().Format("Jan 2 2006")))
+		checkpointsChanged = true
+	}
 
+	root = checkpointsMenu.Pages["root"]
+
+	// The menu's rebuilt here, rather than from the buttons themselves, as the buttons are destroyed in the process.
 	root.OnUpdate = func() {
 
-		maxLabel.SetText([]rune(fmt.Sprintf("Total Cards: %d Cards", len(globals.Project.CurrentPage.Cards))))
+		project := globals.Project
 
-		completionLevel := float32(0)
-		maxLevel := float32(0)
-		totalCompletable := 0
-		completedCards := 0
+		if project != checkpointsShown {
+			checkpointsShown = project
+			comparison = nil
+			checkpointsChanged = true
+		}
 
-		for _, i := range globals.Project.CurrentPage.Cards {
+		if !checkpointsChanged {
+			return
+		}
 
-			if i.Numberable() {
+		checkpointsChanged = false
 
-				maxLevel += i.MaximumCompletionLevel()
-				completionLevel += i.CompletionLevel()
+		checkpointsRoot := checkpointsMenu.Pages["root"]
+		checkpointsRoot.Destroy()
 
-				totalCompletable++
-				if i.Completed() {
-					completedCards++
-				}
+		checkpointsRoot.AddRow(AlignCenter).Add("header", NewLabel("Checkpoints", nil, false, AlignCenter))
 
+		row := checkpointsRoot.AddRow(AlignCenter)
+		row.Add("name", checkpointName)
+		row.Add("create", NewButton("Create", nil, nil, false, func() {
+			if name := strings.TrimSpace(checkpointName.TextAsString()); name != "" {
+				project.CreateCheckpoint(name)
+				checkpointsChanged = true
 			}
+		}))
 
+		if len(project.Checkpoints) == 0 {
+			checkpointsRoot.AddRow(AlignCenter).Add("none", NewLabel("No checkpoints in this project.", nil, false, AlignCenter))
 		}
 
-		if maxLevel == 0 {
-			completedLabel.SetText([]rune("Total Cards Completed: 0 / 0 (0%)"))
-		} else {
-			completedLabel.SetText([]rune(fmt.Sprintf("Total Cards Completed: %d / %d (%d%%)", int(completedCards), int(totalCompletable), int(float32(completedCards)/float32(totalCompletable)*100))))
+		for i := len(project.Checkpoints) - 1; i >= 0; i-- {
+
+			checkpoint := project.Checkpoints[i]
+
+			text := checkpoint.Time.Format("Jan 2 2006 15:04") + "  " + checkpoint.Name
+			if comparison != nil && comparison.Checkpoint == checkpoint {
+				text = "> " + text
+			}
+
+			row = checkpointsRoot.AddRow(AlignLeft)
+			row.Add("", NewLabel(text, nil, false, AlignLeft))
+
+			row = checkpointsRoot.AddRow(AlignRight)
+			row.Add("", NewButton("View", nil, nil, false, func() {
+				project.ViewCheckpoint(checkpoint)
+			}))
+			row.Add("", NewButton("Compare", nil, nil, false, func() {
+				comparison = project.CompareCheckpoint(checkpoint)
+				checkpointsChanged = true
+			}))
+			row.Add("", NewButton("Delete", nil, nil, false, func() {
+				if comparison != nil && comparison.Checkpoint == checkpoint {
+					comparison = nil
+				}
+				project.DeleteCheckpoint(checkpoint)
+				checkpointsChanged = true
+			}))
+
 		}
 
-		if completionLevel < maxLevel {
+		if comparison == nil {
+			return
+		}
+
+		checkpointsRoot.AddRow(AlignCenter).Add("", NewLabel(fmt.Sprintf("Since '%s': %d added, %d removed, %d completed, %d changed", comparison.Checkpoint.Name, len(comparison.Added), len(comparison.Removed), len(comparison.Completed), len(comparison.Changed)), nil, false, AlignCenter))
+
+		listCards := func(title string, cards []*Card, restorable bool) {
+
+			if len(cards) == 0 {
+				return
+			}
+
+			checkpointsRoot.AddRow(AlignLeft).Add("", NewLabel(title, nil, false, AlignLeft))
+
+			for _, c := range cards {
+
+				card := c
+
+				row := checkpointsRoot.AddRow(AlignLeft)
+				row.Add("", NewLabel("   "+undoCardName(card), nil, false, AlignLeft))
+
+				if restorable {
+					row.Add("", NewButton("Restore", nil, nil, false, func() {
+						source := card
+						// Changed Cards belong to the Project, so the Card to restore is the one in the checkpoint.
+						if card.Page.Project == project {
+							for _, page := range project.checkpointProject(comparison.Checkpoint).Pages {
+								if old := page.CardByID(card.ID); old != nil {
+									source = old
+								}
+							}
+						}
+						project.RestoreCheckpointCard(source)
+						comparison = project.CompareCheckpoint(comparison.Checkpoint)
+						checkpointsChanged = true
+					}))
+				}
+
+			}
+
+		}
+
+		listCards("Added:", comparison.Added, false)
+		listCards("Removed:", comparison.Removed, true)
+		listCards("Completed:", comparison.Completed, false)
+		listCards("Changed:", comparison.Changed, true)
+
+		checkpointsRoot.AddRow(AlignLeft).Add("", NewLabel("Pages:", nil, false, AlignLeft))
+
+		for _, p := range project.checkpointProject(comparison.Checkpoint).Pages {
+			page := p
+			row := checkpointsRoot.AddRow(AlignLeft)
+			row.Add("", NewLabel("   "+page.Name, nil, false, AlignLeft))
+			row.Add("", NewButton("Restore Page", nil, nil, false, func() {
+				project.RestoreCheckpointPage(page)
+				comparison = project.CompareCheckpoint(comparison.Checkpoint)
+				checkpointsChanged = true
+			}))
+		}
+
+	}
+
+	// Checkpoint View
+
+	checkpointView := globals.MenuSystem.Add(NewMenu(&sdl.FRect{globals.ScreenSize.X/2 - (640 / 2), 48, 640, 96}, MenuCloseNone), "checkpoint view", false)
+	checkpointView.AnchorMode = MenuAnchorTop
+	checkpointView.Draggable = true
+
+	var checkpointTabsShown *Project
+
+	checkpointView.OnOpen = func() {
+		checkpointTabsShown = nil
+	}
+
+	// The tab bar's rebuilt whenever tabs are opened, closed, or switched between; as with the other lists, that's done here
+	// rather than from its own buttons.
+	checkpointView.Pages["root"].OnUpdate = func() {
+
+		project := globals.Project
+
+		// Loading another Project leaves its checkpoints behind.
+		if len(project.CheckpointTabs) == 0 {
+			checkpointView.Close()
+			return
+		}
+
+		if project == checkpointTabsShown && !project.CheckpointTabsChanged {
+			return
+		}
+
+		checkpointTabsShown = project
+		project.CheckpointTabsChanged = false
+
+		viewRoot := checkpointView.Pages["root"]
+		viewRoot.Destroy()
+
+		tabNames := []string{"Project"}
+		chosen := 0
+
+		for i, checkpoint := range project.CheckpointTabs {
+			tabNames = append(tabNames, checkpoint.Name)
+			if project.CheckpointView != nil && checkpoint.view == project.CheckpointView {
+				chosen = i + 1
+			}
+		}
+
+		tabs := NewButtonGroup(&sdl.FRect{0, 0, 576, 32}, false, nil, nil, tabNames...)
+		tabs.ChosenIndex = chosen
+		tabs.OnChoose = func(index int) {
+			if index == 0 {
+				project.ShowProjectTab()
+			} else if index-1 < len(project.CheckpointTabs) {
+				project.ViewCheckpoint(project.CheckpointTabs[index-1])
+			}
+		}
+		viewRoot.AddRow(AlignCenter).Add("tabs", tabs)
+
+		checkpoint := project.ViewedCheckpoint()
+
+		if checkpoint == nil {
+			checkpointView.Recreate(checkpointView.Rect.W, viewRoot.IdealSize().Y+32)
+			return
+		}
+
+		viewRoot.AddRow(AlignCenter).Add("", NewLabel("Viewing checkpoint '"+checkpoint.Name+"' (read-only)", nil, false, AlignCenter))
+
+		pages := project.CheckpointView.Pages
+		pageNames := []string{}
+		for _, page := range pages {
+			pageNames = append(pageNames, page.Name)
+		}
+
+		row := viewRoot.AddRow(AlignCenter)
+		row.Add("", NewLabel("Page:", nil, false, AlignLeft))
+		row.Add("", NewDropdown(&sdl.FRect{0, 0, 192, 32}, false, func(index int) {
+			if view := project.CheckpointView; view != nil {
+				view.SetCheckpointPage(view.Pages[index])
+			}
+		}, pageNames...))
+		row.Add("", NewButton("Compare", nil, nil, false, func() {
+			comparison = project.CompareCheckpoint(checkpoint)
+			checkpointsChanged = true
+			globals.MenuSystem.Get("checkpoints").Open()
+		}))
+		row.Add("", NewButton("Close Tab", nil, nil, false, func() {
+			project.CloseCheckpointTab(checkpoint)
+		}))
+
+		checkpointView.Recreate(checkpointView.Rect.W, viewRoot.IdealSize().Y+32)
+
+	}
+
+	// Command Palette
+
+	commandPalette := globals.MenuSystem.Add(NewMenu(&sdl.FRect{globals.ScreenSize.X/2 - (640 / 2), 64, 640, 424}, MenuCloseButton), "command palette", false)
+	commandPalette.AnchorMode = MenuAnchorTop
+	commandPalette.Draggable = true
+
+	root = commandPalette.Pages["root"]
+
+	commandSearch := NewLabel("", &sdl.FRect{0, 0, 576, 32}, false, AlignLeft)
+	commandSearch.Editable = true
+	commandSearch.RegexString = RegexNoNewlines
+	root.AddRow(AlignCenter).Add("search", commandSearch)
+
+	commands := []*Command{}
+	commandResults := []*Command{}
+	commandIndex := 0
+	commandButtons := []*Button{}
+	commandKeyLabels := []*Label{}
+	commandRows := []*ContainerRow{}
+
+	runCommand := func(index int) {
+		if index < len(commandResults) {
+			command := commandResults[index]
+			commandPalette.Close()
+			RunCommand(command)
+		}
+	}
+
+	for i := 0; i < 10; i++ {
+
+		row = root.AddRow(AlignCenter)
+		button := NewButton("", &sdl.FRect{0, 0, 384, 32}, nil, false, nil)
+		keyLabel := NewLabel("", &sdl.FRect{0, 0, 192, 32}, false, AlignRight)
+		row.Add("command "+strconv.Itoa(i), button)
+		row.Add("command keys "+strconv.Itoa(i), keyLabel)
+
+		commandButtons = append(commandButtons, button)
+		commandKeyLabels = append(commandKeyLabels, keyLabel)
+		commandRows = append(commandRows, row)
+
+	}
+
+	updateCommandResults := func() {
+
+		commandResults = SearchCommands(commands, commandSearch.TextAsString())
+
+		if commandIndex >= len(commandResults) {
+			commandIndex = len(commandResults) - 1
+		}
+		if commandIndex < 0 {
+			commandIndex = 0
+		}
+
+		// Scroll the list so the highlighted command is always visible.
+		start := 0
+		if commandIndex >= len(commandRows) {
+			start = commandIndex - len(commandRows) + 1
+		}
+
+		for i, row := range commandRows {
+
+			if start+i < len(commandResults) {
+				command := commandResults[start+i]
+				text := command.Name
+				if start+i == commandIndex {
+					text = "> " + text
+				}
+				commandButtons[i].Label.SetText([]rune(text))
+				commandKeyLabels[i].SetText([]rune(command.Keys))
+				index := start + i
+				commandButtons[i].OnPressed = func() { runCommand(index) }
+				row.Visible = true
+			} else {
+				row.Visible = false
+			}
+
+		}
+
+	}
+
+	commandSearch.OnChange = func() {
+		commandIndex = 0
+		updateCommandResults()
+	}
+
+	root.OnUpdate = func() {
+
+		if globals.Keyboard.Key(sdl.K_DOWN).Pressed() {
+			commandIndex++
+			updateCommandResults()
+		} else if globals.Keyboard.Key(sdl.K_UP).Pressed() {
+			commandIndex--
+			updateCommandResults()
+		}
+
+		if globals.Keyboard.Key(sdl.K_RETURN).Pressed() {
+			globals.Keyboard.Key(sdl.K_RETURN).Consume()
+			runCommand(commandIndex)
+		} else if globals.Keybindings.Pressed(KBStopEditing) {
+			commandPalette.Close()
+		}
+
+	}
+
+	commandPalette.OnOpen = func() {
+		commands = AvailableCommands()
+		commandIndex = 0
+		commandSearch.SetText([]rune(""))
+		commandSearch.Editing = true
+		commandSearch.Selection.SelectAll()
+		updateCommandResults()
+	}
+
+	commandPalette.OnClose = func() {
+		if commandSearch.Editing {
+			commandSearch.Editing = false
+			globals.State = StateNeutral
+		}
+	}
+
+	// Tag Filter Menu
+
+	tagFilter := globals.MenuSystem.Add(NewMenu(&sdl.FRect{globals.ScreenSize.X/2 - (512 / 2), 48, 512, 96}, MenuCloseButton), "tag filter", false)
+	tagFilter.AnchorMode = MenuAnchorTop
+	tagFilter.Draggable = true
+
+	root = tagFilter.Pages["root"]
+	row = root.AddRow(AlignCenter)
+	row.Add("", NewLabel("Tags:", nil, false, AlignCenter))
+
+	tagFilterText := NewLabel("", &sdl.FRect{0, 0, 320, 32}, false, AlignLeft)
+	tagFilterText.Editable = true
+	tagFilterText.RegexString = RegexNoNewlines
+
+	row.Add("", NewIconButton(0, 0, &sdl.Rect{176, 96, 32, 32}, false, func() {
+		tagFilterText.SetText([]rune(""))
+	}))
+	row.Add("", tagFilterText)
+
+	row = root.AddRow(AlignCenter)
+	tagPaletteLabel := NewLabel("No tags in project", nil, false, AlignCenter)
+	row.Add("", tagPaletteLabel)
+
+	root.OnUpdate = func() {
+
+		// The filter's applied on update so that it carries over to newly loaded Projects.
+		globals.Project.TagFilter = ParseTags(tagFilterText.TextAsString())
+
+		if tags := globals.Project.Tags(); len(tags) > 0 {
+			tagPaletteLabel.SetText([]rune("Available: " + strings.Join(tags, ", ")))
+		} else {
+			tagPaletteLabel.SetText([]rune("No tags in project"))
+		}
+
+	}
+
+	tagFilter.OnOpen = func() {
+		tagFilterText.Editing = true
+		tagFilterText.Selection.SelectAll()
+	}
+
+	tagFilter.OnClose = func() {
+		tagFilterText.SetText([]rune(""))
+		globals.Project.TagFilter = []string{}
+	}
+
+	// Previous sub-page menu
+
+	prevSubPageMenu := globals.MenuSystem.Add(NewMenu(&sdl.FRect{0, 0, 512, 96}, MenuCloseNone), "prev sub page", false)
+	prevSubPageMenu.Opened = false
+	prevSubPageMenu.Center()
+	prevSubPageMenu.Draggable = true
+
+	row = prevSubPageMenu.Pages["root"].AddRow(AlignCenter)
+	subName := NewLabel("sub page name", nil, false, AlignCenter)
+	row.Add("name", subName)
+
+	root = prevSubPageMenu.Pages["root"]
+	root.OnUpdate = func() {
+		subName.SetText([]rune("Sub-Page: " + globals.Project.CurrentPage.Name))
+		subName.SetMaxSize(512, subName.RendererResult.TextSize.Y)
+		prevSubPageMenu.Recreate(512, prevSubPageMenu.Rect.H)
+	}
+
+	row = prevSubPageMenu.Pages["root"].AddRow(AlignCenter)
+	row.Add("go up", NewButton("Go Up", nil, nil, false, func() {
+		globals.Project.GoUpFromSubpage()
+	}))
+	// Reminders
+
+	remindersMenu := globals.MenuSystem.Add(NewMenu(&sdl.FRect{globals.ScreenSize.X - 560 - 48, 48, 560, 320}, MenuCloseButton), "reminders", false)
+	remindersMenu.Draggable = true
+	remindersMenu.Resizeable = true
+
+	var remindersShown *ReminderScheduler
+
+	remindersMenu.OnOpen = func() {
+		remindersShown = nil
+	}
+
+	root = remindersMenu.Pages["root"]
+
+	// The menu's rebuilt here, rather than from the buttons themselves, as the buttons are destroyed in the process.
+	root.OnUpdate = func() {
+
+		scheduler := globals.Project.Reminders
+
+		if scheduler == remindersShown && !scheduler.Changed {
+			return
+		}
+
+		remindersShown = scheduler
+		scheduler.Changed = false
+
+		remindersRoot := remindersMenu.Pages["root"]
+		remindersRoot.Destroy()
+
+		remindersRoot.AddRow(AlignCenter).Add("header", NewLabel("Reminders", nil, false, AlignCenter))
+
+		if len(scheduler.Reminders) == 0 {
+			remindersRoot.AddRow(AlignCenter).Add("none", NewLabel("No reminders right now.", nil, false, AlignCenter))
+		}
+
+		for i := len(scheduler.Reminders) - 1; i >= 0; i-- {
+
+			reminder := scheduler.Reminders[i]
+
+			row := remindersRoot.AddRow(AlignLeft)
+			row.Add("", NewLabel(reminder.Time.Format(TimestampDisplayFormat)+"  "+undoCardName(reminder.Card), nil, false, AlignLeft))
+
+			row = remindersRoot.AddRow(AlignRight)
+			row.Add("", NewButton("Focus", nil, nil, false, func() {
+				scheduler.Focus(reminder)
+			}))
+
+			for _, o := range ReminderSnoozeOptions {
+				option := o
+				row.Add("", NewButton(option.Label, nil, nil, false, func() {
+					scheduler.Snooze(reminder, option.Duration)
+				}))
+			}
+
+			row.Add("", NewButton("Dismiss", nil, nil, false, func() {
+				scheduler.Remove(reminder)
+			}))
+
+		}
+
+	}
+
+	// Time Report
+
+	timeReportMenu := globals.MenuSystem.Add(NewMenu(&sdl.FRect{globals.ScreenSize.X/2 - (560 / 2), 48, 560, 480}, MenuCloseButton), "time report", false)
+	timeReportMenu.Draggable = true
+	timeReportMenu.Resizeable = true
+
+	root = timeReportMenu.Pages["root"]
+	root.AddRow(AlignCenter).Add("header", NewLabel("Time Report", nil, false, AlignCenter))
+
+	row = root.AddRow(AlignCenter)
+	timeReportGroupings := []string{TimeReportByPage, TimeReportByTag}
+	timeReportGrouping := NewButtonGroup(&sdl.FRect{0, 0, 320, 32}, false, nil, nil, timeReportGroupings...)
+	row.Add("", timeReportGrouping)
+
+	row.Add("", NewButton("Export CSV", nil, nil, false, func() {
+
+		if filename, err := zenity.SelectFileSave(zenity.Title("Export Time Sessions..."), zenity.ConfirmOverwrite(), zenity.FileFilter{Name: "CSV File (*.csv)", Patterns: []string{"*.csv"}}); err == nil {
+
+			if filepath.Ext(filename) != ".csv" {
+				filename += ".csv"
+			}
+
+			if err := globals.Project.ExportTimeSessionsCSV(filename); err != nil {
+				globals.EventLog.Log("Error: Couldn't export time sessions: %s", err.Error())
+			} else {
+				globals.EventLog.Log("Time sessions exported to %s.", filename)
+			}
+
+		}
+
+	}))
+
+	// The report's rows are rebuilt whenever what they show changes.
+	timeReportRows := []*ContainerRow{}
+	timeReportText := ""
+	timeReportRoot := root
+
+	timeReportMenu.OnOpen = func() {
+		timeReportText = ""
+	}
+
+	root.OnUpdate = func() {
+
+		// Totalling the report means parsing every session in the Project, so it's only done once in a while.
+		if timeReportText != "" && globals.Frame%30 != 0 {
+			return
+		}
+
+		lines := []string{}
+		total := time.Duration(0)
+
+		for _, reportRow := range globals.Project.TimeReport(timeReportGroupings[timeReportGrouping.ChosenIndex]) {
+			lines = append(lines, fmt.Sprintf("%s: %s (%d sessions)", reportRow.Name, formatTimeSpent(reportRow.Duration), reportRow.Sessions))
+			total += reportRow.Duration
+		}
+
+		if len(lines) == 0 {
+			lines = append(lines, "No time logged yet.")
+		} else if timeReportGrouping.ChosenIndex == 0 {
+			// Cards with several tags count towards each, so tags aren't totalled.
+			lines = append(lines, "Total: "+formatTimeSpent(total))
+		}
+
+		// The grouping's part of the text so switching it rebuilds the rows.
+		text := timeReportGroupings[timeReportGrouping.ChosenIndex] + "\n" + strings.Join(lines, "\n")
+
+		if text == timeReportText {
+			return
+		}
+
+		timeReportText = text
+
+		existing := map[*ContainerRow]bool{}
+		for _, r := range timeReportRows {
+			existing[r] = true
+			r.Destroy()
+		}
+
+		kept := []*ContainerRow{}
+		for _, r := range timeReportRoot.Rows {
+			if !existing[r] {
+				kept = append(kept, r)
+			}
+		}
+		timeReportRoot.Rows = kept
+
+		timeReportRows = []*ContainerRow{}
+
+		for _, line := range lines {
+			r := timeReportRoot.AddRow(AlignLeft)
+			r.Add("", NewLabel(line, nil, false, AlignLeft))
+			timeReportRows = append(timeReportRows, r)
+		}
+
+	}
+
+	// Stats Menu
+
+	stats := globals.MenuSystem.Add(NewMenu(&sdl.FRect{globals.ScreenSize.X/2 - (700 / 2), 9999, 700, 640}, MenuCloseButton), "stats", false)
+	stats.Draggable = true
+	stats.Resizeable = true
+	stats.AnchorMode = MenuAnchorBottom
+
+	root = stats.Pages["root"]
+
+	row = root.AddRow(AlignCenter)
+	row.Add("", NewLabel("Stats", nil, false, AlignCenter))
+
+	row = root.AddRow(AlignLeft)
+	row.Add("", NewLabel("Scope:", nil, false, AlignLeft))
+	statsScopeChoices := []string{StatsScopePage, StatsScopeWithSubpages, StatsScopeProject}
+	statsScope := NewButtonGroup(&sdl.FRect{0, 0, 480, 32}, false, nil, nil, statsScopeChoices...)
+	row.Add("", statsScope)
+
+	row = root.AddRow(AlignLeft)
+	maxLabel := NewLabel("so many cards existing", nil, false, AlignLeft)
+	row.Add("", maxLabel)
+
+	row = root.AddRow(AlignLeft)
+	completedLabel := NewLabel("so many cards completed", nil, false, AlignLeft)
+	row.Add("", completedLabel)
+	row.ExpandElements = true
+
+	row = root.AddRow(AlignLeft)
+	tagCompletionLabel := NewLabel("so many tags completed", nil, false, AlignLeft)
+	row.Add("", tagCompletionLabel)
+
+	row = root.AddRow(AlignLeft)
+	frameCompletionLabel := NewLabel("so many frames completed", nil, false, AlignLeft)
+	row.Add("", frameCompletionLabel)
+
+	row = root.AddRow(AlignLeft)
+	contentTypeLabel := NewLabel("so many cards of each type", nil, false, AlignLeft)
+	row.Add("", contentTypeLabel)
+
+	row = root.AddRow(AlignLeft)
+	row.Add("", NewSpacer(&sdl.FRect{0, 0, 32, 1}))
+
+	row = root.AddRow(AlignLeft)
+	row.Add("time estimation", NewLabel("Time Estimation:", nil, false, AlignLeft))
+
+	timeNumber := NewLabel("15", &sdl.FRect{0, 0, 128, 32}, false, AlignCenter)
+	timeNumber.Editable = true
+	timeNumber.RegexString = RegexOnlyDigits
+	row.Add("", timeNumber)
+
+	timeUnitChoices := []string{
+		"Minutes",
+		"Hours",
+		"Days",
+		"Weeks",
+		"Months",
+	}
+
+	row = root.AddRow(AlignLeft)
+	row.ExpandElements = true
+	timeUnit := NewButtonGroup(&sdl.FRect{0, 0, 32, 32}, false, func(index int) {}, globals.Settings.Get("time unit"), timeUnitChoices...)
+
+	// timeUnit := NewDropdown(nil, false, func(index int) {}, timeUnitChoices...)
+	row.Add("", timeUnit)
+
+	row = root.AddRow(AlignLeft)
+	estimatedTime := NewLabel("Time estimation label", nil, false, AlignLeft)
+	row.Add("", estimatedTime)
+	row.ExpandElements = true
+
+	row = root.AddRow(AlignLeft)
+	row.Add("", NewLabel("Limit time estimation read-out to same as units: ", nil, false, AlignLeft))
+
+	limitTimeCheckbox := NewCheckbox(0, 0, false, nil)
+	row.Add("", limitTimeCheckbox)
+
+	row = root.AddRow(AlignLeft)
+	row.Add("", NewSpacer(&sdl.FRect{0, 0, 32, 1}))
+
+	row = root.AddRow(AlignLeft)
+	row.Add("", NewLabel("Burndown:", nil, false, AlignLeft))
+	burndownPeriodChoices := []string{BurndownPerDay, BurndownPerWeek}
+	burndownPeriod := NewButtonGroup(&sdl.FRect{0, 0, 320, 32}, false, nil, nil, burndownPeriodChoices...)
+	row.Add("", burndownPeriod)
+
+	var burndown *Burndown
+	burndownScope := -1
+
+	row.Add("", NewButton("Export CSV", nil, nil, false, func() {
+
+		if burndown == nil {
+			return
+		}
+
+		if filename, err := zenity.SelectFileSave(zenity.Title("Export Burndown Data..."), zenity.ConfirmOverwrite(), zenity.FileFilter{Name: "CSV File (*.csv)", Patterns: []string{"*.csv"}}); err == nil {
+
+			if filepath.Ext(filename) != ".csv" {
+				filename += ".csv"
+			}
+
+			if err := burndown.ExportCSV(filename); err != nil {
+				globals.EventLog.Log("Error: Couldn't export burndown data: %s", err.Error())
+			} else {
+				globals.EventLog.Log("Burndown data exported to [%s].", filename)
+			}
+
+		} else if err != zenity.ErrCanceled {
+			globals.EventLog.Log("Error: %s", err.Error())
+		}
+
+	}))
+
+	row = root.AddRow(AlignCenter)
+	burndownChart := NewChart(&sdl.FRect{0, 0, 640, 240})
+	row.Add("", burndownChart)
+
+	row = root.AddRow(AlignLeft)
+	velocityLabel := NewLabel("Velocity label", nil, false, AlignLeft)
+	row.Add("", velocityLabel)
+	row.ExpandElements = true
+
+	row = root.AddRow(AlignLeft)
+	row.Add("", NewSpacer(&sdl.FRect{0, 0, 32, 1}))
+
+	row = root.AddRow(AlignLeft)
+	row.Add("", NewLabel("By Page:", nil, false, AlignLeft))
+
+	// The per-page table's rows are rebuilt whenever what they show changes.
+	pageTableRows := []*ContainerRow{}
+	pageTableText := ""
+	statsRoot := root
+
+	updatePageTable := func(pages []*Page) {
+
+		rows := []string{}
+		for _, page := range pages {
+			pageStats := NewPageStats(page)
+			rows = append(rows, fmt.Sprintf("%s: %d / %d (%d%%)", page.DisplayName(), pageStats.Completed, pageStats.Total, pageStats.Percentage()))
+		}
+
+		if text := strings.Join(rows, "\n"); text == pageTableText {
+			return
+		} else {
+			pageTableText = text
+		}
+
+		existing := map[*ContainerRow]bool{}
+		for _, r := range pageTableRows {
+			existing[r] = true
+			r.Destroy()
+		}
+
+		kept := []*ContainerRow{}
+		for _, r := range statsRoot.Rows {
+			if !existing[r] {
+				kept = append(kept, r)
+			}
+		}
+		statsRoot.Rows = kept
+
+		pageTableRows = []*ContainerRow{}
+
+		for i, p := range pages {
+			page := p
+			r := statsRoot.AddRow(AlignLeft)
+			r.Add("", NewButton(rows[i], nil, nil, false, func() {
+				globals.Project.SetPage(page)
+			}))
+			pageTableRows = append(pageTableRows, r)
+		}
+
+	}
+
+	root.OnUpdate = func() {
+
+		scopePages := globals.Project.StatsPages(statsScopeChoices[statsScope.ChosenIndex])
+
+		cards := []*Card{}
+		for _, page := range scopePages {
+			for _, card := range page.Cards {
+				if card.Valid {
+					cards = append(cards, card)
+				}
+			}
+		}
+
+		maxLabel.SetText([]rune(fmt.Sprintf("Total Cards: %d Cards", len(cards))))
+
+		completionLevel := float32(0)
+		maxLevel := float32(0)
+		totalCompletable := 0
+		completedCards := 0
+
+		tagCompletable := map[string]int{}
+		tagCompleted := map[string]int{}
+
+		for _, i := range cards {
+
+			if i.Numberable() {
+
+				maxLevel += i.MaximumCompletionLevel()
+				completionLevel += i.CompletionLevel()
+
+				totalCompletable++
+				if i.Completed() {
+					completedCards++
+				}
+
+				for _, tag := range i.Tags() {
+					tagCompletable[tag]++
+					if i.Completed() {
+						tagCompleted[tag]++
+					}
+				}
+
+			}
+
+		}
+
+		tagCompletion := []string{}
+		for _, tag := range globals.Project.Tags() {
+			if total := tagCompletable[tag]; total > 0 {
+				tagCompletion = append(tagCompletion, fmt.Sprintf("%s: %d / %d (%d%%)", tag, tagCompleted[tag], total, tagCompleted[tag]*100/total))
+			}
+		}
+
+		if len(tagCompletion) > 0 {
+			tagCompletionLabel.SetText([]rune("Completed By Tag: " + strings.Join(tagCompletion, ", ")))
+		} else {
+			tagCompletionLabel.SetText([]rune("Completed By Tag: No tagged cards"))
+		}
+
+		frameCompletion := []string{}
+
+		for _, card := range cards {
+
+			frame, ok := card.Contents.(*FrameContents)
+			if !ok {
+				continue
+			}
+
+			total := 0
+			completed := 0
+
+			for _, contained := range frame.ContainedCards() {
+				if contained.Numberable() {
+					total++
+					if contained.Completed() {
+						completed++
+					}
+				}
+			}
+
+			if total > 0 {
+				frameCompletion = append(frameCompletion, fmt.Sprintf("%s: %d / %d (%d%%)", frame.NameLabel.TextAsString(), completed, total, completed*100/total))
+			}
+
+		}
+
+		if len(frameCompletion) > 0 {
+			frameCompletionLabel.SetText([]rune("Completed By Frame: " + strings.Join(frameCompletion, ", ")))
+		} else {
+			frameCompletionLabel.SetText([]rune("Completed By Frame: No frames with tasks"))
+		}
+
+		typeCounts, contentTypes := ContentTypeCounts(cards)
+		typeCountText := []string{}
+		for _, contentType := range contentTypes {
+			typeCountText = append(typeCountText, fmt.Sprintf("%s: %d", contentType, typeCounts[contentType]))
+		}
+
+		if len(typeCountText) > 0 {
+			contentTypeLabel.SetText([]rune("Cards By Type: " + strings.Join(typeCountText, ", ")))
+		} else {
+			contentTypeLabel.SetText([]rune("Cards By Type: No cards"))
+		}
+
+		updatePageTable(scopePages)
+
+		if maxLevel == 0 {
+			completedLabel.SetText([]rune("Total Cards Completed: 0 / 0 (0%)"))
+		} else {
+			completedLabel.SetText([]rune(fmt.Sprintf("Total Cards Completed: %d / %d (%d%%)", int(completedCards), int(totalCompletable), int(float32(completedCards)/float32(totalCompletable)*100))))
+		}
+
+		if completionLevel < maxLevel {
 			var unit time.Duration
 			t := timeUnitChoices[timeUnit.ChosenIndex]
 			switch t {
@@ -1876,6 +3529,46 @@ func ConstructMenus() {
 			estimatedTime.SetText([]rune(fmt.Sprintf("All tasks completed.")))
 		}
 
+		// Only measure the burndown once in a while, as it's comparatively expensive and only changes with the time.
+		period := burndownPeriodChoices[burndownPeriod.ChosenIndex]
+
+		if burndown == nil || burndown.Period != period || burndownScope != statsScope.ChosenIndex || globals.Frame%30 == 0 {
+
+			burndown = NewBurndown(cards, period, time.Now())
+			burndownScope = statsScope.ChosenIndex
+
+			remaining := []float32{}
+			completed := []float32{}
+			labels := []string{}
+
+			for _, point := range burndown.Points {
+				remaining = append(remaining, float32(point.Remaining))
+				completed = append(completed, float32(point.Completed))
+				labels = append(labels, point.Start.Format("Jan 2"))
+			}
+
+			burndownChart.Series = []*ChartSeries{
+				{Values: completed, Color: getThemeColor(GUICompletedColor), Bars: true},
+				{Values: remaining, Color: getThemeColor(GUIFontColor)},
+				{Values: burndown.ForecastValues(len(burndown.Points)), Start: len(burndown.Points) - 1, Color: getThemeColor(GUICheckboxColor)},
+			}
+			burndownChart.Labels = labels
+
+			unit := "day"
+			if period == BurndownPerWeek {
+				unit = "week"
+			}
+
+			if burndown.Remaining == 0 {
+				velocityLabel.SetText([]rune(fmt.Sprintf("Velocity: %.1f tasks per %s. No tasks remaining.", burndown.Velocity, unit)))
+			} else if burndown.Forecast.IsZero() {
+				velocityLabel.SetText([]rune(fmt.Sprintf("Velocity: No tasks completed recently; %d tasks remaining.", burndown.Remaining)))
+			} else {
+				velocityLabel.SetText([]rune(fmt.Sprintf("Velocity: %.1f tasks per %s; %d tasks remaining, forecast done by %s.", burndown.Velocity, unit, burndown.Remaining, burndown.Forecast.Format("Mon Jan 2 2006"))))
+			}
+
+		}
+
 	}
 
 	// Map palette menu
@@ -1,15 +1,18 @@
 package main
 
 import (
+	"math"
 	"os"
 	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gabriel-vasile/mimetype"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
+	"github.com/veandco/go-sdl2/sdl"
 	"golang.design/x/clipboard"
 )
 
@@ -25,6 +28,7 @@ type Page struct {
 	Selection      *Selection
 	Name           string
 	UpdateStacks   bool
+	UpdateHidden   bool // Whether the Cards hidden within collapsed Frames need to be found again
 	Drawables      []*Drawable
 	ToRaise        []*Card
 
@@ -32,8 +36,18 @@ type Page struct {
 	Pan            Point
 	Zoom           float32
 
+	// The view last recorded in the UndoHistory, and the one the camera's currently heading to (and since when).
+	undoPan       Point
+	undoZoom      float32
+	viewPan       Point
+	viewZoom      float32
+	viewChangedAt time.Time
+
 	Linking              *Card
 	DeserializationLinks []string
+
+	HoveredCard *Card
+	HoverStart  float64
 }
 
 var globalPageID = uint64(0)
@@ -50,6 +64,8 @@ func NewPage(project *Project) *Page {
 		Drawables:      []*Drawable{},
 		ToRaise:        []*Card{},
 		Zoom:           1,
+		undoZoom:       1,
+		viewZoom:       1,
 	}
 
 	globalPageID++
@@ -81,6 +97,31 @@ func (page *Page) Update() {
 
 	}
 
+	// Cards lying within collapsed Frames are hidden. Which Cards those are only changes when Cards move, are added or removed
+	// (all of which update the Stacks), or when a Frame's collapsed or uncollapsed.
+	if page.UpdateStacks || page.UpdateHidden {
+
+		hidden := map[*Card]bool{}
+
+		for _, card := range page.Cards {
+			if frame, ok := card.Contents.(*FrameContents); ok && card.Collapsed != CollapsedNone {
+				for _, contained := range frame.ContainedCards() {
+					hidden[contained] = true
+				}
+			}
+		}
+
+		for _, card := range page.Cards {
+			card.Hidden = hidden[card]
+			if card.Hidden && card.selected {
+				page.Selection.Remove(card)
+			}
+		}
+
+		page.UpdateHidden = false
+
+	}
+
 	for _, card := range reversed {
 		card.Update()
 	}
@@ -88,9 +129,11 @@ func (page *Page) Update() {
 	if page.IsCurrent() {
 
 		// We only want to set the pan and zoom of a page if it's not loading the project (as it sets the page to be current to take screenshots for subpages).
-		if !page.Project.Loading && !page.IgnoreWritePan {
+		// Likewise, the camera belongs to a checkpoint while one's being viewed.
+		if !page.Project.Loading && !page.IgnoreWritePan && page.Project.CheckpointView == nil {
 			page.Pan = page.Project.Camera.Position
 			page.Zoom = page.Project.Camera.Zoom
+			page.updateView()
 		}
 
 		if page.UpdateStacks {
@@ -107,6 +150,9 @@ func (page *Page) Update() {
 
 			page.UpdateStacks = false
 
+			// Cards could have moved while updating them above, after the hidden Cards were found.
+			page.UpdateHidden = true
+
 			page.SendMessage(NewMessage(MessageStacksUpdated, nil, nil))
 
 		}
@@ -141,6 +187,8 @@ func (page *Page) Draw() {
 		}
 	}
 
+	page.drawHoveredTimestamps(sorted)
+
 	// This needs to be later than Update() so mouse buttons can be consumed in a Card's Draw() loop, for example, before the Selection detects the mouse button press
 	page.Selection.Update()
 
@@ -183,6 +231,74 @@ func (page *Page) Draw() {
 
 }
 
+// PageViewSettleTime is how long the camera has to stay put before where it's moved to is recorded in the UndoHistory.
+const PageViewSettleTime = 0.5
+
+// updateView records the view in the UndoHistory once the camera's come to rest somewhere new. Moving the view isn't an undo
+// step of its own (so undoing doesn't step back through every pan before reaching an edit, and panning after undoing doesn't
+// lose the redo steps); it's undone along with the next change made.
+func (page *Page) updateView() {
+
+	camera := page.Project.Camera
+
+	if camera.TargetPosition != page.viewPan || camera.TargetZoom != page.viewZoom {
+		page.viewPan = camera.TargetPosition
+		page.viewZoom = camera.TargetZoom
+		page.viewChangedAt = time.Now()
+		return
+	}
+
+	if (page.viewPan == page.undoPan && page.viewZoom == page.undoZoom) || time.Since(page.viewChangedAt).Seconds() < PageViewSettleTime {
+		return
+	}
+
+	history := page.Project.UndoHistory
+	history.Track(page)
+	page.undoPan = page.viewPan
+	page.undoZoom = page.viewZoom
+	history.CaptureAlong(NewUndoState(page))
+
+}
+
+// CardHoverDelay is how long the mouse has to rest over a Card for its timestamps to be shown.
+const CardHoverDelay = 0.75
+
+// drawHoveredTimestamps shows the timestamps of the Card the mouse is resting over.
+func (page *Page) drawHoveredTimestamps(sorted []*Card) {
+
+	var hovered *Card
+
+	if page.IsCurrent() && globals.State == StateNeutral {
+		mouse := globals.Mouse.WorldPosition()
+		for i := len(sorted) - 1; i >= 0; i-- {
+			if card := sorted[i]; card.Valid && !card.Hidden && mouse.Inside(card.Rect) {
+				hovered = card
+				break
+			}
+		}
+	}
+
+	if hovered != page.HoveredCard || globals.Mouse.RelativeMovement().Length() > 0 {
+		page.HoveredCard = hovered
+		page.HoverStart = globals.Time
+	}
+
+	if hovered == nil || hovered.Dragging || hovered.Resizing != "" || globals.Time-page.HoverStart < CardHoverDelay {
+		return
+	}
+
+	pos := globals.Mouse.Position().Add(Point{16, 16})
+
+	lines := append(hovered.TimestampSummary(), hovered.RecurrenceSummary()...)
+	lines = append(lines, hovered.ReminderSummary()...)
+
+	for _, line := range lines {
+		DrawLabel(pos, line)
+		pos.Y += 24
+	}
+
+}
+
 func (page *Page) Serialize() string {
 
 	pageData := "{}"
@@ -223,6 +339,70 @@ func (page *Page) DeserializePageData(data string) {
 		page.Zoom = 1
 	}
 
+	page.undoPan, page.viewPan = page.Pan, page.Pan
+	page.undoZoom, page.viewZoom = page.Zoom, page.Zoom
+
+}
+
+// UndoData returns the Page's name and view; its Cards have lanes of their own.
+func (page *Page) UndoData() string {
+	data, _ := sjson.Set("{}", "name", page.Name)
+	data, _ = sjson.Set(data, "pan", page.undoPan)
+	data, _ = sjson.Set(data, "zoom", page.undoZoom)
+	return data
+}
+
+func (page *Page) ApplyUndoData(data string, deletion bool) {
+
+	project := page.Project
+
+	// Redoing the creation of a sub-page that was undone.
+	if project.PageIndex(page) < 0 {
+		project.Pages = append(project.Pages, page)
+	}
+
+	page.Name = gjson.Get(data, "name").String()
+
+	if pan := gjson.Get(data, "pan"); pan.Exists() {
+		page.undoPan = Point{float32(pan.Get("X").Float()), float32(pan.Get("Y").Float())}
+		page.undoZoom = float32(gjson.Get(data, "zoom").Float())
+		if page.undoZoom == 0 {
+			page.undoZoom = 1
+		}
+		page.Pan, page.viewPan = page.undoPan, page.undoPan
+		page.Zoom, page.viewZoom = page.undoZoom, page.undoZoom
+		if page.IsCurrent() {
+			project.Camera.TargetPosition = page.Pan
+			project.Camera.SetZoom(page.Zoom)
+		}
+	}
+
+}
+
+// UndoUncreate removes a sub-page when its creation's undone; it's kept around so it can be added back if it's redone.
+func (page *Page) UndoUncreate() {
+
+	project := page.Project
+
+	if page.UpwardPage == nil || project.PageIndex(page) < 0 {
+		return
+	}
+
+	if project.CurrentPage == page {
+		project.SetPage(page.UpwardPage)
+	}
+
+	project.RemovePage(page)
+
+}
+
+// UndoPage returns the Page itself if it's being viewed; otherwise, it returns the Page its sub-page Card is on, if it has
+// one, so the change can be seen.
+func (page *Page) UndoPage() *Page {
+	if page.UpwardPage != nil && !page.IsCurrent() {
+		return page.UpwardPage
+	}
+	return page
 }
 
 func (page *Page) DeserializeCards(data string) {
@@ -296,6 +476,12 @@ func (page *Page) CreateNewCard(contentType string) *Card {
 	page.Cards = append(page.Cards, newCard)
 	newCard.Valid = true
 
+	if !page.Project.Loading {
+		now := time.Now()
+		newCard.setTimestamp(TimestampCreated, now)
+		newCard.setTimestamp(TimestampModified, now)
+	}
+
 	page.Project.UndoHistory.Capture(NewUndoState(newCard))
 
 	globals.EventLog.Log("Created new Card.")
@@ -303,6 +489,20 @@ func (page *Page) CreateNewCard(contentType string) *Card {
 
 }
 
+// CreateCardWithID creates a new Card that has the given ID, rather than a fresh one (i.e. for a Card being brought back from
+// another version of the project).
+func (page *Page) CreateCardWithID(id int64) *Card {
+
+	card := page.CreateNewCard(ContentTypeCheckbox)
+	card.ID = id
+	if globalCardID <= id {
+		globalCardID = id + 1
+	}
+
+	return card
+
+}
+
 func (page *Page) CardByID(id int64) *Card {
 	for _, card := range page.Cards {
 		if card.ID == id {
@@ -431,6 +631,142 @@ func (page *Page) Raise(card *Card) {
 
 }
 
+// StackOrder returns the visible Cards on the Page in Stack order; Stacks are ordered top-to-bottom, then left-to-right, and the Cards
+// within them from the top of the Stack down.
+func (page *Page) StackOrder() []*Card {
+
+	tops := []*Card{}
+
+	for _, card := range page.Cards {
+		if !card.Hidden && card.Stack.Above == nil {
+			tops = append(tops, card)
+		}
+	}
+
+	sort.SliceStable(tops, func(i, j int) bool {
+		if tops[i].Rect.Y == tops[j].Rect.Y {
+			return tops[i].Rect.X < tops[j].Rect.X
+		}
+		return tops[i].Rect.Y < tops[j].Rect.Y
+	})
+
+	order := []*Card{}
+
+	for _, top := range tops {
+		order = append(order, top)
+		for _, card := range top.Stack.Tail() {
+			if !card.Hidden {
+				order = append(order, card)
+			}
+		}
+	}
+
+	return order
+
+}
+
+// NearestCardInDirection returns the closest visible Card to the one given in the specified direction, searching outwards through the Grid
+// in a widening cone. It returns nil if there's no Card in that direction.
+func (page *Page) NearestCardInDirection(card *Card, dx, dy int) *Card {
+
+	gs := globals.GridSize
+	center := Point{card.Rect.X + (card.Rect.W / 2), card.Rect.Y + (card.Rect.H / 2)}
+
+	for dist := float32(1); dist <= 64; dist++ {
+
+		spread := dist * gs
+		var strip *sdl.FRect
+
+		if dx > 0 {
+			strip = &sdl.FRect{card.Rect.X + card.Rect.W + ((dist - 1) * gs), card.Rect.Y - spread, gs, card.Rect.H + (spread * 2)}
+		} else if dx < 0 {
+			strip = &sdl.FRect{card.Rect.X - (dist * gs), card.Rect.Y - spread, gs, card.Rect.H + (spread * 2)}
+		} else if dy > 0 {
+			strip = &sdl.FRect{card.Rect.X - spread, card.Rect.Y + card.Rect.H + ((dist - 1) * gs), card.Rect.W + (spread * 2), gs}
+		} else {
+			strip = &sdl.FRect{card.Rect.X - spread, card.Rect.Y - (dist * gs), card.Rect.W + (spread * 2), gs}
+		}
+
+		var nearest *Card
+		nearestDistance := float32(math.MaxFloat32)
+
+		for _, other := range page.Grid.Select(strip).Cards() {
+
+			// Frames that overlap the Card (i.e. that it's sitting in) aren't in any direction from it.
+			if other == card || other.Hidden || other.Rect.HasIntersection(card.Rect) {
+				continue
+			}
+
+			otherCenter := Point{other.Rect.X + (other.Rect.W / 2), other.Rect.Y + (other.Rect.H / 2)}
+			diff := otherCenter.Sub(center)
+
+			if (dx > 0 && diff.X <= 0) || (dx < 0 && diff.X >= 0) || (dy > 0 && diff.Y <= 0) || (dy < 0 && diff.Y >= 0) {
+				continue
+			}
+
+			if d := otherCenter.DistanceSquared(center); d < nearestDistance {
+				nearest = other
+				nearestDistance = d
+			}
+
+		}
+
+		if nearest != nil {
+			return nearest
+		}
+
+	}
+
+	return nil
+
+}
+
+// SlideSelectedCards moves the selected Cards (and anything contained within selected Frames) by the given amount, returning false
+// without moving anything if doing so would make them collide with another Card.
+func (page *Page) SlideSelectedCards(dx, dy float32) bool {
+
+	moving := map[*Card]bool{}
+
+	for card := range page.Selection.Cards {
+		moving[card] = true
+		if frame, ok := card.Contents.(*FrameContents); ok {
+			for _, contained := range frame.ContainedCards() {
+				moving[contained] = true
+			}
+		}
+	}
+
+	if len(moving) == 0 {
+		return false
+	}
+
+	for card := range moving {
+
+		// Frames sit behind other Cards, so they don't collide with anything.
+		if card.ContentType == ContentTypeFrame {
+			continue
+		}
+
+		dest := &sdl.FRect{card.Rect.X + dx, card.Rect.Y + dy, card.Rect.W, card.Rect.H}
+
+		for _, other := range page.Grid.Select(dest).Cards() {
+			if !moving[other] && !other.Hidden && other.ContentType != ContentTypeFrame && other.Rect.HasIntersection(dest) {
+				return false
+			}
+		}
+
+	}
+
+	for card := range moving {
+		card.Move(dx, dy)
+		card.captureChange()
+		card.CreateUndoState = false
+	}
+
+	return true
+
+}
+
 func (page *Page) HandleDroppedFiles(filePath string) {
 
 	mime, _ := mimetype.DetectFile(filePath)
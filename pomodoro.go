@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const (
+	PomodoroPhaseWork       = "Work"
+	PomodoroPhaseShortBreak = "Short Break"
+	PomodoroPhaseLongBreak  = "Long Break"
+)
+
+// pomodoroRowCount is how many rows of settings a Timer shows in Pomodoro mode.
+const pomodoroRowCount = 6
+
+// Pomodoro settings are stored in the Timer Card's Properties; lengths are in minutes.
+var pomodoroDefaults = []struct {
+	Property string
+	Label    string
+	Value    float64
+}{
+	{"pomodoro work", "Work:  ", 25},
+	{"pomodoro short break", "Break:  ", 5},
+	{"pomodoro long break", "Long Break:  ", 15},
+	{"pomodoro cycles", "Cycles:  ", 4},
+}
+
+func (tc *TimerContents) addPomodoroRows() {
+
+	tc.PomodoroPhase = PomodoroPhaseWork
+	tc.PomodoroStatus = NewLabel("Work 0/4", nil, true, AlignCenter)
+
+	row := tc.Container.AddRow(AlignCenter)
+	row.Add("pomodoro status", tc.PomodoroStatus)
+	tc.PomodoroRows = append(tc.PomodoroRows, row)
+
+	for _, setting := range pomodoroDefaults {
+
+		prop := tc.Card.Properties.Get(setting.Property)
+		if !prop.IsNumber() {
+			prop.SetRaw(setting.Value)
+		}
+
+		spinner := NewNumberSpinner(&sdl.FRect{0, 0, 128, 32}, true, prop)
+		spinner.SetLimits(1, 999)
+
+		row = tc.Container.AddRow(AlignCenter)
+		row.Add("", NewLabel(setting.Label, nil, true, AlignRight))
+		row.Add(setting.Property, spinner)
+		tc.PomodoroRows = append(tc.PomodoroRows, row)
+
+	}
+
+	row = tc.Container.AddRow(AlignCenter)
+	row.Add("", NewLabel("Trigger After Cycles:  ", nil, true, AlignRight))
+	row.Add("pomodoro trigger", NewCheckbox(0, 0, true, tc.Card.Properties.Get("pomodoro trigger")))
+	tc.PomodoroRows = append(tc.PomodoroRows, row)
+
+}
+
+// pomodoroLength returns how long the given phase of a Pomodoro lasts.
+func (tc *TimerContents) pomodoroLength(phase string) time.Duration {
+
+	property := "pomodoro work"
+	if phase == PomodoroPhaseShortBreak {
+		property = "pomodoro short break"
+	} else if phase == PomodoroPhaseLongBreak {
+		property = "pomodoro long break"
+	}
+
+	minutes := tc.Card.Properties.Get(property).AsFloat()
+	if minutes < 1 {
+		minutes = 1
+	}
+
+	return time.Duration(minutes * float64(time.Minute))
+
+}
+
+func (tc *TimerContents) pomodoroCycles() int {
+	cycles := int(tc.Card.Properties.Get("pomodoro cycles").AsFloat())
+	if cycles < 1 {
+		cycles = 1
+	}
+	return cycles
+}
+
+func (tc *TimerContents) resetPomodoro() {
+	tc.PomodoroPhase = PomodoroPhaseWork
+	tc.PomodoroCycle = 0
+	tc.TimerValue = 0
+	tc.Pie.FillPercent = 0
+}
+
+func (tc *TimerContents) pomodoroStatusText() string {
+	if tc.PomodoroPhase == PomodoroPhaseWork {
+		return fmt.Sprintf("%s %d/%d", tc.PomodoroPhase, tc.PomodoroCycle+1, tc.pomodoroCycles())
+	}
+	return fmt.Sprintf("%s (%d/%d done)", tc.PomodoroPhase, tc.PomodoroCycle, tc.pomodoroCycles())
+}
+
+// updatePomodoro moves a running Pomodoro on to its next phase once the current one's done. Unlike a countdown, a Pomodoro
+// keeps running from one phase to the next; the alarm goes off at the end of each.
+func (tc *TimerContents) updatePomodoro() {
+
+	if tc.TimerValue < tc.pomodoroLength(tc.PomodoroPhase) {
+		return
+	}
+
+	message := tc.advancePomodoro()
+
+	tc.TimerValue = 0
+	tc.Pie.FillPercent = 0
+
+	globals.EventLog.Log(message)
+	tc.alarm(message)
+
+}
+
+// advancePomodoro moves the Pomodoro on to its next phase, returning a message saying what's next.
+func (tc *TimerContents) advancePomodoro() string {
+
+	name := tc.Name.TextAsString()
+	message := ""
+
+	switch tc.PomodoroPhase {
+
+	case PomodoroPhaseWork:
+
+		tc.PomodoroCycle++
+
+		if tc.PomodoroCycle >= tc.pomodoroCycles() {
+
+			tc.PomodoroPhase = PomodoroPhaseLongBreak
+			message = fmt.Sprintf("Pomodoro [%s]: all %d cycles done; take a %s long break.", name, tc.PomodoroCycle, formatTimeSpent(tc.pomodoroLength(tc.PomodoroPhase)))
+
+			if tc.Card.Properties.Get("pomodoro trigger").AsBool() {
+				tc.triggerLinks()
+			}
+
+		} else {
+			tc.PomodoroPhase = PomodoroPhaseShortBreak
+			message = fmt.Sprintf("Pomodoro [%s]: cycle %d of %d done; take a %s break.", name, tc.PomodoroCycle, tc.pomodoroCycles(), formatTimeSpent(tc.pomodoroLength(tc.PomodoroPhase)))
+		}
+
+	case PomodoroPhaseLongBreak:
+
+		tc.PomodoroCycle = 0
+		tc.PomodoroPhase = PomodoroPhaseWork
+		message = fmt.Sprintf("Pomodoro [%s]: long break's over; starting a new set.", name)
+
+	default:
+
+		tc.PomodoroPhase = PomodoroPhaseWork
+		message = fmt.Sprintf("Pomodoro [%s]: break's over; back to work.", name)
+
+	}
+
+	return message
+
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"time"
 
 	"github.com/blang/semver"
 	"github.com/ncruces/zenity"
@@ -63,6 +64,45 @@ type Project struct {
 	Modified     bool
 
 	LoadConfirmationTo string
+
+	TagColors map[string]Color
+	TagFilter []string
+
+	Scripts *ScriptEngine
+
+	Reminders *ReminderScheduler
+
+	Checkpoints           []*Checkpoint
+	CheckpointView        *Project      // The checkpoint currently being viewed in place of the Project, if any
+	CheckpointTabs        []*Checkpoint // Checkpoints open in tabs of their own, alongside the Project
+	CheckpointTabsChanged bool          // If the open tabs (or which one's shown) have changed since this was last reset
+	ReadOnly              bool
+
+	// The contents and modification time of the project file as of the last load or save, and how each Card was serialized
+	// then; used to detect and merge changes made to the file outside of MasterPlan.
+	SyncedData      string
+	SyncedCards     map[*Card]string
+	FileModTime     time.Time
+	notifiedModTime time.Time
+	lastFileCheck   time.Time
+
+	// Cards changed both here and on disk that are waiting to be resolved after a merge, and the file they were merged from.
+	MergeConflicts        []*MergeConflict
+	MergeConflictsChanged bool
+	mergeData             string
+	mergeModTime          time.Time
+}
+
+// tagPalette is the set of colors new tags cycle through when they're first used in a Project.
+var tagPalette = []Color{
+	NewColor(230, 85, 85, 255),
+	NewColor(240, 160, 60, 255),
+	NewColor(230, 210, 70, 255),
+	NewColor(110, 200, 90, 255),
+	NewColor(70, 190, 190, 255),
+	NewColor(80, 130, 230, 255),
+	NewColor(160, 100, 220, 255),
+	NewColor(220, 100, 170, 255),
 }
 
 func NewProject() *Project {
@@ -71,9 +111,16 @@ func NewProject() *Project {
 		Camera: NewCamera(),
 		// Pages:           []*Page{},
 		LastCardType: ContentTypeCheckbox,
+		TagColors:    map[string]Color{},
+		TagFilter:    []string{},
+		SyncedCards:  map[*Card]string{},
 	}
 
 	project.UndoHistory = NewUndoHistory(project)
+	project.Scripts = NewScriptEngine(project)
+	project.Reminders = NewReminderScheduler(project, systemClock{})
+	project.Reminders.Notify = AlertReminder
+	project.Reminders.Log = func(message string) { globals.EventLog.Log(message) }
 
 	globalPageID = 0
 
@@ -114,6 +161,49 @@ func (project *Project) PageIndex(page *Page) int {
 	return -1
 }
 
+// TagColor returns the color the given tag is drawn with, adding the tag to the Project's tag palette if it's not already in there.
+func (project *Project) TagColor(tag string) Color {
+
+	if _, exists := project.TagColors[tag]; !exists {
+		project.TagColors[tag] = tagPalette[len(project.TagColors)%len(tagPalette)].Clone()
+	}
+
+	return project.TagColors[tag]
+
+}
+
+// Tags returns all of the tags in the Project's tag palette, sorted alphabetically.
+func (project *Project) Tags() []string {
+
+	tags := []string{}
+
+	for tag := range project.TagColors {
+		tags = append(tags, tag)
+	}
+
+	sort.Strings(tags)
+
+	return tags
+
+}
+
+// PassesTagFilter returns if the Card has any of the tags in the Project's tag filter. If the filter is empty, every Card passes.
+func (project *Project) PassesTagFilter(card *Card) bool {
+
+	if len(project.TagFilter) == 0 {
+		return true
+	}
+
+	for _, tag := range project.TagFilter {
+		if card.HasTag(tag) {
+			return true
+		}
+	}
+
+	return false
+
+}
+
 func (project *Project) CreateGridTexture() {
 
 	guiTex := globals.Resources.Get(LocalRelativePath("assets/gui.png")).AsImage()
@@ -160,13 +250,40 @@ func (project *Project) CreateGridTexture() {
 
 func (project *Project) Update() {
 
-	project.Camera.Update()
+	viewingCheckpoint := project.CheckpointView != nil
+
+	if viewingCheckpoint {
+
+		// The checkpoint being viewed gets the input (and the camera), but the Project carries on in the background, so its
+		// timers, deadlines, reminders, and so on don't stop while it's out of view.
+		project.CheckpointView.Update()
+
+		keybindingsOn := globals.Keybindings.On
+		globals.Keybindings.On = false
+		globals.Mouse.HiddenPosition = true
+
+		defer func() {
+			globals.Keybindings.On = keybindingsOn
+			globals.Mouse.HiddenPosition = false
+		}()
+
+	} else {
+
+		project.Camera.Update()
 
-	globals.Mouse.HiddenPosition = false
+		globals.Mouse.HiddenPosition = false
 
-	globals.Mouse.ApplyCursor()
+		globals.Mouse.ApplyCursor()
 
-	globals.Mouse.SetCursor("normal")
+		globals.Mouse.SetCursor("normal")
+
+	}
+
+	// Read-only Projects (checkpoints being viewed) can only be looked around.
+	if project.ReadOnly {
+		globals.InputText = []rune{}
+		return
+	}
 
 	for _, page := range project.Pages {
 		if page.ReferenceCount > 0 {
@@ -174,14 +291,19 @@ func (project *Project) Update() {
 		}
 	}
 
-	globals.Mouse.HiddenPosition = false
-
-	project.GlobalShortcuts()
+	if !viewingCheckpoint {
+		globals.Mouse.HiddenPosition = false
+		project.GlobalShortcuts()
+	}
 
 	globals.InputText = []rune{}
 
 	project.UndoHistory.Update()
 
+	project.Reminders.Update()
+
+	project.CheckFileChanges()
+
 	// This should only be true for a total of essentially 1 or 2 frames, immediately after loading
 	project.Loading = false
 
@@ -189,6 +311,11 @@ func (project *Project) Update() {
 
 func (project *Project) Draw() {
 
+	if project.CheckpointView != nil {
+		project.CheckpointView.Draw()
+		return
+	}
+
 	drawGridPiece := func(x, y float32) {
 		globals.Renderer.CopyF(project.GridTexture.Texture, nil, &sdl.FRect{x, y, project.GridTexture.Size.X, project.GridTexture.Size.Y})
 	}
@@ -249,6 +376,13 @@ func (project *Project) Draw() {
 
 func (project *Project) Save() {
 
+	if project.ChangedOnDisk() {
+		confirm := globals.MenuSystem.Get("confirm overwrite")
+		confirm.Center()
+		confirm.Open()
+		return
+	}
+
 	saveData, _ := sjson.Set("{}", "version", globals.Version.String())
 
 	saveData, _ = sjson.Set(saveData, "pan", project.Camera.TargetPosition)
@@ -256,52 +390,12 @@ func (project *Project) Save() {
 
 	savedImages := map[string]string{}
 
-	pageData := "["
-
-	pagesToSave := []uint64{0}
-
-	var searchForLiveSubpages func(page *Page)
-
-	searchForLiveSubpages = func(page *Page) {
-		for _, card := range page.Cards {
-			if card.ContentType == ContentTypeSubpage {
-				subpage := uint64(card.Properties.Get("subpage").AsFloat())
-
-				// It's possible to copy a Sub-Page Card, so we'll keep it being a reference, I think?
-				existsAlready := false
-				for _, p := range pagesToSave {
-					if p == subpage {
-						existsAlready = true
-						break
-					}
-				}
-
-				if existsAlready {
-					continue
-				}
-
-				pagesToSave = append(pagesToSave, subpage)
-				searchForLiveSubpages(project.Pages[subpage])
-			}
-		}
-	}
-
-	searchForLiveSubpages(project.Pages[0])
-
-	sort.SliceStable(pagesToSave, func(i, j int) bool { return pagesToSave[i] < pagesToSave[j] })
+	saveData, _ = sjson.SetRaw(saveData, "pages", project.serializePages())
 
-	for i := range pagesToSave {
-		page := project.Pages[pagesToSave[i]]
-		pageData += page.Serialize()
-		if i < len(pagesToSave)-1 {
-			pageData += ", "
-		}
+	if len(project.Checkpoints) > 0 {
+		saveData, _ = sjson.SetRaw(saveData, "checkpoints", project.serializeCheckpoints())
 	}
 
-	pageData += "]"
-
-	saveData, _ = sjson.SetRaw(saveData, "pages", pageData)
-
 	for _, page := range project.Pages {
 
 		for _, card := range page.Cards {
@@ -332,6 +426,13 @@ func (project *Project) Save() {
 
 	saveData, _ = sjson.Set(saveData, "savedimages", savedImages)
 
+	tagColors := map[string]string{}
+	for tag, color := range project.TagColors {
+		tagColors[tag] = color.ToHexString()
+	}
+
+	saveData, _ = sjson.Set(saveData, "tags", tagColors)
+
 	saveData = gjson.Get(saveData, "@pretty").String()
 
 	if file, err := os.Create(project.Filepath); err != nil {
@@ -341,12 +442,70 @@ func (project *Project) Save() {
 		file.Close()
 	}
 
+	project.MarkSynced(saveData)
+
+	if globals.Settings.Get(SettingsPersistentUndoHistory).AsBool() {
+		project.SaveUndoHistory(saveData)
+	}
+
 	globals.EventLog.Log("Project saved successfully.")
 
 	project.Modified = false
 
 }
 
+// serializePages returns the Project's Pages (the root Page and any Pages reachable from it through Sub-Page Cards) as a
+// JSON array, as they're saved in the project file.
+func (project *Project) serializePages() string {
+
+	pageData := "["
+
+	pagesToSave := []uint64{0}
+
+	var searchForLiveSubpages func(page *Page)
+
+	searchForLiveSubpages = func(page *Page) {
+		for _, card := range page.Cards {
+			if card.ContentType == ContentTypeSubpage {
+				subpage := uint64(card.Properties.Get("subpage").AsFloat())
+
+				// It's possible to copy a Sub-Page Card, so we'll keep it being a reference, I think?
+				existsAlready := false
+				for _, p := range pagesToSave {
+					if p == subpage {
+						existsAlready = true
+						break
+					}
+				}
+
+				if existsAlready {
+					continue
+				}
+
+				pagesToSave = append(pagesToSave, subpage)
+				searchForLiveSubpages(project.Pages[subpage])
+			}
+		}
+	}
+
+	searchForLiveSubpages(project.Pages[0])
+
+	sort.SliceStable(pagesToSave, func(i, j int) bool { return pagesToSave[i] < pagesToSave[j] })
+
+	for i := range pagesToSave {
+		page := project.Pages[pagesToSave[i]]
+		pageData += page.Serialize()
+		if i < len(pagesToSave)-1 {
+			pageData += ", "
+		}
+	}
+
+	pageData += "]"
+
+	return pageData
+
+}
+
 func (project *Project) SaveAs() {
 
 	if filename, err := zenity.SelectFileSave(zenity.Title("Save MasterPlan Project..."), zenity.ConfirmOverwrite(), zenity.FileFilter{Name: "Project File (*.plan)", Patterns: []string{"*.plan"}}); err == nil {
@@ -357,6 +516,9 @@ func (project *Project) SaveAs() {
 
 		project.Filepath = filename
 
+		// The file dialog has already confirmed overwriting the file, if it exists.
+		project.FileModTime = time.Time{}
+
 		project.Save()
 
 	} else if err != zenity.ErrCanceled {
@@ -419,6 +581,10 @@ func OpenProjectFrom(filename string) {
 			newProject.UndoHistory.On = false
 			globals.NextProject = newProject
 
+			for tag, color := range gjson.Get(json, "tags").Map() {
+				newProject.TagColors[tag] = ColorFromHexString(color.String())
+			}
+
 			savedImageFileNames := map[string]string{}
 
 			for fpName, imgData := range gjson.Get(json, "savedimages").Map() {
@@ -443,24 +609,12 @@ func OpenProjectFrom(filename string) {
 			} else {
 
 				// v0.8.0-alpha.3 and below just had one page, but organized into a folder; this is no longer done.
-				for i := 0; i < len(gjson.Get(json, "pages").Array())-1; i++ {
-					newProject.AddPage()
-				}
-
-				for p, pageData := range gjson.Get(json, "pages").Array() {
-					page := newProject.Pages[p]
-					page.DeserializePageData(pageData.String())
-					if globalPageID < page.ID {
-						globalPageID = page.ID + 1
-					}
-				}
-
-				for p, pageData := range gjson.Get(json, "pages").Array() {
-					newProject.Pages[p].DeserializeCards(pageData.String())
-				}
+				newProject.deserializePages(gjson.Get(json, "pages").Array())
 
 			}
 
+			newProject.deserializeCheckpoints(gjson.Get(json, "checkpoints").Array())
+
 			for _, page := range newProject.Pages {
 
 				for _, card := range page.Cards {
@@ -482,15 +636,7 @@ func OpenProjectFrom(filename string) {
 
 			// newProject.Camera.Update()
 
-			// Settle the elements in - we do this a few times because it seems like things might take two steps (create card, set properties, create links, etc)
-			globals.Renderer.SetClipRect(nil)
-			for i := 0; i < 3; i++ {
-				for _, page := range newProject.Pages {
-					newProject.CurrentPage = page
-					page.Update()
-					page.Draw()
-				}
-			}
+			newProject.settle()
 
 			// for _, page := range newProject.Pages {
 			// 	newProject.CurrentPage = page
@@ -516,8 +662,14 @@ func OpenProjectFrom(filename string) {
 
 			newProject.UndoHistory.Update()
 
+			newProject.MarkSynced(json)
+
 			newProject.Modified = false
 			newProject.UndoHistory.MinimumFrame = 1
+
+			if globals.Settings.Get(SettingsPersistentUndoHistory).AsBool() {
+				newProject.LoadUndoHistory(json)
+			}
 			globals.EventLog.On = true
 
 			globals.EventLog.Log("Project loaded successfully.")
@@ -528,13 +680,49 @@ func OpenProjectFrom(filename string) {
 
 }
 
+// deserializePages creates the Project's Pages, and the Cards on them, from the pages saved in a project file.
+func (project *Project) deserializePages(pages []gjson.Result) {
+
+	for i := 0; i < len(pages)-1; i++ {
+		project.AddPage()
+	}
+
+	for p, pageData := range pages {
+		page := project.Pages[p]
+		page.DeserializePageData(pageData.String())
+		if globalPageID < page.ID {
+			globalPageID = page.ID + 1
+		}
+	}
+
+	for p, pageData := range pages {
+		project.Pages[p].DeserializeCards(pageData.String())
+	}
+
+}
+
+// settle updates and draws a freshly loaded Project's Pages a few times, so its Cards settle in.
+func (project *Project) settle() {
+
+	// We do this a few times because it seems like things might take two steps (create card, set properties, create links, etc)
+	globals.Renderer.SetClipRect(nil)
+	for i := 0; i < 3; i++ {
+		for _, page := range project.Pages {
+			project.CurrentPage = page
+			page.Update()
+			page.Draw()
+		}
+	}
+
+}
+
 func (project *Project) Destroy() {
 
 }
 
 func (project *Project) MouseActions() {
 
-	if globals.State == StateNeutral {
+	if globals.State == StateNeutral && !project.ReadOnly {
 
 		if globals.Mouse.Button(sdl.BUTTON_LEFT).PressedTimes(2) && globals.Settings.Get(SettingsDoubleClickMode).AsString() != DoubleClickNothing {
 
@@ -714,6 +902,11 @@ func (project *Project) GlobalShortcuts() {
 			newCard = project.CurrentPage.CreateNewCard(ContentTypeSubpage)
 			globals.Keybindings.Shortcuts[KBNewSubpageCard].ConsumeKeys()
 
+		} else if globals.Keybindings.Pressed(KBNewFrameCard) {
+
+			newCard = project.CurrentPage.CreateNewCard(ContentTypeFrame)
+			globals.Keybindings.Shortcuts[KBNewFrameCard].ConsumeKeys()
+
 		}
 
 		if newCard != nil {
@@ -771,6 +964,19 @@ func (project *Project) GlobalShortcuts() {
 			project.Open()
 		}
 
+		if globals.Keybindings.Pressed(KBRunLastScript) {
+			if project.Scripts.LastScript != "" {
+				project.Scripts.Run(project.Scripts.LastScript)
+			} else {
+				globals.MenuSystem.Get("scripts").Open()
+			}
+		}
+
+		if globals.Keybindings.Pressed(KBCommandPalette) {
+			globals.MenuSystem.Get("command palette").Open()
+			globals.Keybindings.Shortcuts[KBCommandPalette].ConsumeKeys()
+		}
+
 		if globals.Keybindings.Pressed(KBFindNext) || globals.Keybindings.Pressed(KBFindPrev) {
 			if !globals.MenuSystem.Get("find").Opened {
 				globals.MenuSystem.Get("find").Open()
@@ -781,6 +987,10 @@ func (project *Project) GlobalShortcuts() {
 			project.Camera.FocusOn(true, project.CurrentPage.Selection.AsSlice()...)
 		}
 
+		if globals.State == StateNeutral {
+			project.KeyboardNavigation()
+		}
+
 		if globals.Keybindings.Pressed(KBSubpageOpen) {
 			project.GoUpFromSubpage()
 		}
@@ -789,6 +999,117 @@ func (project *Project) GlobalShortcuts() {
 
 }
 
+// KeyboardNavigation handles selecting, sliding, and editing Cards using the keyboard.
+func (project *Project) KeyboardNavigation() {
+
+	page := project.CurrentPage
+	order := page.StackOrder()
+
+	if len(order) == 0 {
+		return
+	}
+
+	// The Card navigation starts from is the first selected Card in Stack order.
+	var current *Card
+	for _, card := range order {
+		if card.selected {
+			current = card
+			break
+		}
+	}
+
+	selectCard := func(card *Card, addToSelection bool) {
+		if card == nil {
+			return
+		}
+		if !addToSelection {
+			page.Selection.Clear()
+		}
+		page.Selection.Add(card)
+		project.Camera.FocusOn(false, card)
+	}
+
+	directions := map[string]Point{
+		KBSelectCardUp:    {0, -1},
+		KBSelectCardRight: {1, 0},
+		KBSelectCardDown:  {0, 1},
+		KBSelectCardLeft:  {-1, 0},
+	}
+
+	for shortcutName, dir := range directions {
+
+		if globals.Keybindings.Pressed(shortcutName) {
+
+			if current == nil {
+				selectCard(order[0], false)
+			} else {
+				selectCard(page.NearestCardInDirection(current, int(dir.X), int(dir.Y)), globals.Keybindings.Pressed(KBAddToSelection))
+			}
+
+			globals.Keybindings.Shortcuts[shortcutName].ConsumeKeys()
+
+		}
+
+	}
+
+	slides := map[string]Point{
+		KBSlideCardUp:    {0, -1},
+		KBSlideCardRight: {1, 0},
+		KBSlideCardDown:  {0, 1},
+		KBSlideCardLeft:  {-1, 0},
+	}
+
+	for shortcutName, dir := range slides {
+
+		if globals.Keybindings.Pressed(shortcutName) {
+			page.SlideSelectedCards(dir.X*globals.GridSize, dir.Y*globals.GridSize)
+			globals.Keybindings.Shortcuts[shortcutName].ConsumeKeys()
+		}
+
+	}
+
+	// Tab opens the palette for a selected Map instead.
+	if current != nil && current.ContentType == ContentTypeMap && len(page.Selection.Cards) == 1 {
+		return
+	}
+
+	if globals.Keybindings.Pressed(KBSelectNextCard) || globals.Keybindings.Pressed(KBSelectPrevCard) {
+
+		index := -1
+
+		for i, card := range order {
+			if card == current {
+				index = i
+				break
+			}
+		}
+
+		if globals.Keybindings.Pressed(KBSelectPrevCard) {
+			index--
+			if index < 0 {
+				index = len(order) - 1
+			}
+			globals.Keybindings.Shortcuts[KBSelectPrevCard].ConsumeKeys()
+		} else {
+			index++
+			if index >= len(order) {
+				index = 0
+			}
+			globals.Keybindings.Shortcuts[KBSelectNextCard].ConsumeKeys()
+		}
+
+		selectCard(order[index], false)
+
+	}
+
+	if current != nil && len(page.Selection.Cards) == 1 && globals.Keybindings.Pressed(KBEditCard) {
+		if current.Contents.StartEditing() {
+			globals.Keybindings.Shortcuts[KBEditCard].ConsumeKeys()
+		}
+	}
+
+}
+
 func (project *Project) GoUpFromSubpage() {
 
 	if project.CurrentPage.UpwardPage != nil {
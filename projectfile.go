@@ -0,0 +1,443 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// ProjectFileCheckInterval is how often the project file is checked for changes made outside of MasterPlan (e.g. by a git pull).
+const ProjectFileCheckInterval = time.Second
+
+type projectFileCard struct {
+	Page uint64
+	Data string
+}
+
+// projectFileCards returns the serialized Cards in a project file, indexed by their IDs.
+func projectFileCards(data string) map[int64]projectFileCard {
+
+	cards := map[int64]projectFileCard{}
+
+	for _, pageData := range gjson.Get(data, "pages").Array() {
+		pageID := pageData.Get("id").Uint()
+		for _, cardData := range pageData.Get("cards").Array() {
+			cards[cardData.Get("id").Int()] = projectFileCard{Page: pageID, Data: cardData.Get("@ugly").Raw}
+		}
+	}
+
+	return cards
+
+}
+
+// fileModTime returns the modification time of the Project's file, or the zero time if it can't be read.
+func (project *Project) fileModTime() time.Time {
+
+	if project.Filepath == "" {
+		return time.Time{}
+	}
+
+	info, err := os.Stat(project.Filepath)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return info.ModTime()
+
+}
+
+// MarkSynced records that the Project matches the given contents of its project file, so later changes to the file (or to
+// the Project) can be told apart.
+func (project *Project) MarkSynced(fileData string) {
+
+	project.SyncedData = fileData
+	project.FileModTime = project.fileModTime()
+	project.SyncedCards = map[*Card]string{}
+	project.clearMergeConflicts()
+
+	for _, page := range project.Pages {
+		for _, card := range page.Cards {
+			project.SyncedCards[card] = card.Serialize()
+		}
+	}
+
+}
+
+// ChangedOnDisk returns if the project file was modified by something else since the Project was loaded or saved.
+func (project *Project) ChangedOnDisk() bool {
+
+	if project.FileModTime.IsZero() {
+		return false
+	}
+
+	modTime := project.fileModTime()
+
+	return !modTime.IsZero() && !modTime.Equal(project.FileModTime)
+
+}
+
+// CheckFileChanges looks to see if the project file has been changed on disk; if so, the Project is reloaded in place if it
+// has no unsaved changes, or the user is asked what to do if it does.
+func (project *Project) CheckFileChanges() {
+
+	if project.Loading || globals.NextProject != nil || time.Since(project.lastFileCheck) < ProjectFileCheckInterval {
+		return
+	}
+
+	project.lastFileCheck = time.Now()
+
+	if !project.ChangedOnDisk() {
+		return
+	}
+
+	modTime := project.fileModTime()
+
+	// Don't ask again about a change we've already asked about.
+	if modTime.Equal(project.notifiedModTime) {
+		return
+	}
+
+	if !project.Modified {
+		project.Reload()
+		globals.EventLog.Log("Project reloaded, as it was changed outside of MasterPlan.")
+	} else {
+		project.notifiedModTime = modTime
+		changed := globals.MenuSystem.Get("project changed on disk")
+		changed.Center()
+		changed.Open()
+	}
+
+}
+
+// Reload loads the Project again from its file, staying on the same page and keeping the camera where it is.
+func (project *Project) Reload() {
+
+	pageID := project.CurrentPage.ID
+	pan := project.Camera.TargetPosition
+	zoom := project.Camera.TargetZoom
+
+	OpenProjectFrom(project.Filepath)
+
+	if next := globals.NextProject; next != nil && next.Filepath == project.Filepath {
+
+		for _, page := range next.Pages {
+			if page.ID == pageID {
+				next.SetPage(page)
+				break
+			}
+		}
+
+		next.Camera.JumpTo(pan, zoom)
+
+	}
+
+}
+
+// KeepLocalChanges dismisses a change to the project file on disk; the next save overwrites it.
+func (project *Project) KeepLocalChanges() {
+
+	if data, err := os.ReadFile(project.Filepath); err == nil {
+		project.SyncedData = string(data)
+	}
+
+	project.FileModTime = project.fileModTime()
+	project.clearMergeConflicts()
+
+}
+
+// A MergeConflict is a Card that was changed both here and in the project file on disk, waiting for the user to choose which
+// version to keep.
+type MergeConflict struct {
+	ID     int64
+	Page   uint64
+	Local  *Card  // The Card here; nil if it was deleted here
+	Theirs string // The Card as serialized on disk; empty if it was deleted there
+}
+
+// Description returns a line describing the conflict, for displaying.
+func (conflict *MergeConflict) Description() string {
+
+	if conflict.Theirs == "" {
+		return fmt.Sprintf("%s: changed here, deleted on disk", undoCardName(conflict.Local))
+	}
+
+	if conflict.Local == nil || !conflict.Local.Valid {
+		name := strings.TrimSpace(strings.Split(gjson.Get(conflict.Theirs, "properties.description").String(), "\n")[0])
+		if len([]rune(name)) > 24 {
+			name = string([]rune(name)[:24]) + "..."
+		}
+		if name == "" {
+			name = gjson.Get(conflict.Theirs, "contents").String()
+		}
+		return fmt.Sprintf("%s: deleted here, changed on disk", name)
+	}
+
+	return fmt.Sprintf("%s: changed here and on disk", undoCardName(conflict.Local))
+
+}
+
+// MergeFromDisk merges the changes made to the project file on disk into the Project, matching Cards up by ID. Cards changed
+// only on disk take the disk's version and Cards changed only locally keep theirs. Cards changed in both places are listed in
+// MergeConflicts for the user to resolve; the Project isn't considered in sync with the file until they all are.
+func (project *Project) MergeFromDisk() {
+
+	fileData, err := os.ReadFile(project.Filepath)
+	if err != nil {
+		globals.EventLog.Log("Error: Couldn't read project file for merging: %s", err.Error())
+		return
+	}
+
+	theirData := string(fileData)
+	modTime := project.fileModTime()
+
+	base := projectFileCards(project.SyncedData)
+	theirs := projectFileCards(theirData)
+
+	localCards := map[int64]*Card{}
+	for _, page := range project.Pages {
+		for _, card := range page.Cards {
+			if card.Valid {
+				localCards[card.ID] = card
+			}
+		}
+	}
+
+	modifiedLocally := func(card *Card) bool {
+		synced, exists := project.SyncedCards[card]
+		return !exists || synced != card.Serialize()
+	}
+
+	taken := []*Card{}
+	conflicts := []*MergeConflict{}
+	updated, added, removed, skipped := 0, 0, 0, 0
+
+	for id, theirCard := range theirs {
+
+		baseCard, inBase := base[id]
+
+		// Unchanged on disk, so whatever we have locally wins.
+		if inBase && baseCard.Data == theirCard.Data {
+			continue
+		}
+
+		local, existsLocally := localCards[id]
+
+		if existsLocally {
+
+			// Either the Card's been edited here too, or it's a new Card here that happens to have the same ID as a new Card there.
+			if !inBase || modifiedLocally(local) {
+				conflicts = append(conflicts, &MergeConflict{ID: id, Page: theirCard.Page, Local: local, Theirs: theirCard.Data})
+				continue
+			}
+
+			local.Deserialize(theirCard.Data)
+			taken = append(taken, local)
+			updated++
+
+		} else if inBase {
+
+			// Deleted here, but changed there.
+			conflicts = append(conflicts, &MergeConflict{ID: id, Page: theirCard.Page, Theirs: theirCard.Data})
+
+		} else {
+
+			page := project.pageByID(theirCard.Page)
+
+			if page == nil {
+				skipped++
+				continue
+			}
+
+			card := page.CreateCardWithID(id)
+			card.Deserialize(theirCard.Data)
+			taken = append(taken, card)
+			added++
+
+		}
+
+	}
+
+	for id, baseCard := range base {
+
+		if _, stillThere := theirs[id]; stillThere {
+			continue
+		}
+
+		if local, exists := localCards[id]; exists {
+			if modifiedLocally(local) {
+				conflicts = append(conflicts, &MergeConflict{ID: id, Page: baseCard.Page, Local: local})
+			} else {
+				local.Page.DeleteCards(local)
+				removed++
+			}
+		}
+
+	}
+
+	for _, page := range project.Pages {
+		page.UpdateLinks()
+		page.UpdateStacks = true
+	}
+
+	for _, card := range taken {
+		project.captureMerged(card)
+	}
+
+	project.Modified = true
+	project.notifiedModTime = modTime
+
+	project.MergeConflicts = conflicts
+	project.MergeConflictsChanged = true
+	project.mergeData = theirData
+	project.mergeModTime = modTime
+
+	globals.EventLog.Log("Merged changes from disk: %d Cards updated, %d added, %d removed.", updated, added, removed)
+
+	if skipped > 0 {
+		globals.EventLog.Log("%d new Cards on pages that don't exist here were skipped.", skipped)
+	}
+
+	if len(conflicts) > 0 {
+		globals.EventLog.Log("%d Cards were changed both here and on disk; choose which version of each to keep.", len(conflicts))
+		conflictsMenu := globals.MenuSystem.Get("merge conflicts")
+		conflictsMenu.Center()
+		conflictsMenu.Open()
+	} else {
+		project.finishMerge()
+	}
+
+}
+
+// ResolveConflict resolves a MergeConflict by keeping the local version of the Card, or by taking the version on disk. Once
+// the last conflict's resolved, the Project's considered in sync with the file it was merged from.
+func (project *Project) ResolveConflict(conflict *MergeConflict, takeTheirs bool) {
+
+	for i, c := range project.MergeConflicts {
+		if c == conflict {
+			project.MergeConflicts = append(project.MergeConflicts[:i], project.MergeConflicts[i+1:]...)
+			project.MergeConflictsChanged = true
+			break
+		}
+	}
+
+	if takeTheirs {
+
+		local := conflict.Local
+		if local != nil && !local.Valid {
+			local = nil
+		}
+
+		if conflict.Theirs == "" {
+
+			if local != nil {
+				local.Page.DeleteCards(local)
+				delete(project.SyncedCards, local)
+			}
+
+		} else if local != nil {
+
+			local.Deserialize(conflict.Theirs)
+			local.Page.UpdateLinks()
+			local.Page.UpdateStacks = true
+			project.captureMerged(local)
+
+		} else if page := project.pageByID(conflict.Page); page != nil {
+
+			card := page.CreateCardWithID(conflict.ID)
+			card.Deserialize(conflict.Theirs)
+			page.UpdateLinks()
+			page.UpdateStacks = true
+			project.captureMerged(card)
+
+		} else {
+			globals.EventLog.Log("Error: The page the Card was on no longer exists, so it couldn't be restored.")
+		}
+
+		project.Modified = true
+
+	}
+
+	if len(project.MergeConflicts) == 0 {
+		project.finishMerge()
+		globals.EventLog.Log("All merge conflicts resolved.")
+	}
+
+}
+
+// captureMerged records a Card's state after it's been merged from disk as an undo step, and as in sync with the file.
+func (project *Project) captureMerged(card *Card) {
+	project.UndoHistory.Capture(NewUndoState(card))
+	card.CreateUndoState = false
+	project.SyncedCards[card] = card.Serialize()
+}
+
+// clearMergeConflicts drops any conflicts left over from a merge, as the project file's been overwritten or dismissed since.
+func (project *Project) clearMergeConflicts() {
+	if len(project.MergeConflicts) > 0 {
+		project.MergeConflicts = nil
+		project.MergeConflictsChanged = true
+	}
+	project.mergeData = ""
+}
+
+// finishMerge marks the Project as in sync with the project file it was last merged from.
+func (project *Project) finishMerge() {
+	project.SyncedData = project.mergeData
+	project.FileModTime = project.mergeModTime
+	project.mergeData = ""
+}
+
+// UndoHistoryPath returns the path of the sidecar file the Project's UndoHistory is saved to.
+func (project *Project) UndoHistoryPath() string {
+	if project.Filepath == "" {
+		return ""
+	}
+	return project.Filepath + ".undo"
+}
+
+// SaveUndoHistory writes the UndoHistory to its sidecar file next to the project file; projectData is the project file's
+// contents as just saved.
+func (project *Project) SaveUndoHistory(projectData string) {
+
+	frameCap := int(globals.Settings.Get(SettingsUndoHistoryFrameCap).AsFloat())
+
+	if err := os.WriteFile(project.UndoHistoryPath(), []byte(project.UndoHistory.Serialize(projectData, frameCap)), 0644); err != nil {
+		globals.EventLog.Log("Error: Couldn't save undo history: %s", err.Error())
+	}
+
+}
+
+// LoadUndoHistory restores the UndoHistory from its sidecar file, if there is one and it was saved along with this version of
+// the project file.
+func (project *Project) LoadUndoHistory(projectData string) {
+
+	data, err := os.ReadFile(project.UndoHistoryPath())
+	if err != nil {
+		return
+	}
+
+	if !project.UndoHistory.Deserialize(string(data), projectData) {
+		globals.EventLog.Log("Warning: The saved undo history doesn't match the project file, so it wasn't loaded.")
+	}
+
+}
+
+// DiscardUndoHistory deletes the Project's saved undo history (i.e. before sharing the project file), and clears the history
+// kept in memory so it isn't saved again.
+func (project *Project) DiscardUndoHistory() {
+
+	if path := project.UndoHistoryPath(); path != "" && FileExists(path) {
+		if err := os.Remove(path); err != nil {
+			globals.EventLog.Log("Error: Couldn't delete undo history: %s", err.Error())
+			return
+		}
+	}
+
+	project.UndoHistory.Reset()
+
+	globals.EventLog.Log("Undo history discarded.")
+
+}
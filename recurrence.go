@@ -0,0 +1,414 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// A recurring task's rule is stored in the form ParseRecurrence() reads, the start of its current period as an RFC 3339 time,
+// and its history as a JSON array of objects with a "start" Unix time and whether that period was "completed".
+const (
+	RecurrenceProperty        = "recurrence"
+	RecurrencePeriodProperty  = "recurrence period"
+	RecurrenceHistoryProperty = "recurrence history"
+)
+
+const (
+	RecurrenceDaily    = "daily"
+	RecurrenceWeekdays = "weekdays"
+	RecurrenceWeekly   = "weekly"
+	RecurrenceEvery    = "every"
+	RecurrenceMonthly  = "monthly"
+)
+
+// RecurrenceHistoryLimit is the most past periods a recurring task remembers.
+const RecurrenceHistoryLimit = 100
+
+var recurrenceWeekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// A Recurrence is when a recurring task resets: every day, every weekday, on certain days of the week, every N days, or on
+// the first of every month. Periods start at midnight.
+type Recurrence struct {
+	Kind     string
+	Days     map[time.Weekday]bool // For weekly rules
+	Interval int                   // For every N days
+}
+
+// ParseRecurrence parses a recurrence rule, like "daily", "weekdays", "weekly on mon, thu", "every 3 days", or "monthly".
+func ParseRecurrence(text string) (*Recurrence, bool) {
+
+	words := strings.Fields(strings.ToLower(strings.ReplaceAll(text, ",", " ")))
+
+	if len(words) == 0 {
+		return nil, false
+	}
+
+	switch words[0] {
+
+	case RecurrenceDaily, RecurrenceWeekdays, RecurrenceMonthly:
+
+		if len(words) > 1 {
+			return nil, false
+		}
+		return &Recurrence{Kind: words[0]}, true
+
+	case RecurrenceWeekly:
+
+		recurrence := &Recurrence{Kind: RecurrenceWeekly, Days: map[time.Weekday]bool{}}
+
+		for _, word := range words[1:] {
+			if word == "on" || word == "and" {
+				continue
+			}
+			if len(word) < 3 {
+				return nil, false
+			}
+			day, exists := recurrenceWeekdays[word[:3]]
+			if !exists {
+				return nil, false
+			}
+			recurrence.Days[day] = true
+		}
+
+		if len(recurrence.Days) == 0 {
+			return nil, false
+		}
+
+		return recurrence, true
+
+	case RecurrenceEvery:
+
+		// "every day" and "every 3 days"
+		if len(words) == 2 && words[1] == "day" {
+			return &Recurrence{Kind: RecurrenceDaily}, true
+		}
+
+		if len(words) != 3 || (words[2] != "days" && words[2] != "day") {
+			return nil, false
+		}
+
+		interval, err := strconv.Atoi(words[1])
+		if err != nil || interval < 1 {
+			return nil, false
+		}
+
+		return &Recurrence{Kind: RecurrenceEvery, Interval: interval}, true
+
+	}
+
+	return nil, false
+
+}
+
+// String returns the rule in the form ParseRecurrence() reads.
+func (recurrence *Recurrence) String() string {
+
+	switch recurrence.Kind {
+
+	case RecurrenceWeekly:
+
+		days := []string{}
+		for day := time.Sunday; day <= time.Saturday; day++ {
+			if recurrence.Days[day] {
+				days = append(days, strings.ToLower(day.String()[:3]))
+			}
+		}
+		return "weekly on " + strings.Join(days, ", ")
+
+	case RecurrenceEvery:
+		return fmt.Sprintf("every %d days", recurrence.Interval)
+
+	}
+
+	return recurrence.Kind
+
+}
+
+// startsOn returns if a period starts on the given day; it's only used for rules that go by the day of the week.
+func (recurrence *Recurrence) startsOn(day time.Time) bool {
+
+	switch recurrence.Kind {
+	case RecurrenceWeekdays:
+		return day.Weekday() != time.Saturday && day.Weekday() != time.Sunday
+	case RecurrenceWeekly:
+		return recurrence.Days[day.Weekday()]
+	}
+
+	return true
+
+}
+
+// PeriodStart returns when the period that t falls in started. For every N days, periods are counted from when the rule
+// was set, so this just returns the start of the day.
+func (recurrence *Recurrence) PeriodStart(t time.Time) time.Time {
+
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+
+	switch recurrence.Kind {
+
+	case RecurrenceMonthly:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+
+	case RecurrenceWeekdays, RecurrenceWeekly:
+		for i := 0; i < 7 && !recurrence.startsOn(day); i++ {
+			day = day.AddDate(0, 0, -1)
+		}
+
+	}
+
+	return day
+
+}
+
+// NextPeriod returns when the period after the one starting at start begins.
+func (recurrence *Recurrence) NextPeriod(start time.Time) time.Time {
+
+	switch recurrence.Kind {
+
+	case RecurrenceMonthly:
+		return start.AddDate(0, 1, 0)
+
+	case RecurrenceEvery:
+		return start.AddDate(0, 0, recurrence.Interval)
+
+	case RecurrenceWeekdays, RecurrenceWeekly:
+		next := start.AddDate(0, 0, 1)
+		for i := 0; i < 7 && !recurrence.startsOn(next); i++ {
+			next = next.AddDate(0, 0, 1)
+		}
+		return next
+
+	}
+
+	return start.AddDate(0, 0, 1)
+
+}
+
+// RecurrencePeriod is the outcome of one past period of a recurring task.
+type RecurrencePeriod struct {
+	Start     time.Time
+	Completed bool
+}
+
+// Recurrence returns the Card's recurrence rule, or nil if it doesn't recur. The parsed rule is cached, as it's checked every
+// frame.
+func (card *Card) Recurrence() *Recurrence {
+
+	if !card.Numberable() {
+		return nil
+	}
+
+	if !card.recurrenceCached {
+
+		card.recurrence = nil
+
+		if prop, exists := card.Properties.Props[RecurrenceProperty]; exists && prop.IsString() {
+			card.recurrence, _ = ParseRecurrence(prop.AsString())
+		}
+
+		card.recurrenceCached = true
+
+	}
+
+	return card.recurrence
+
+}
+
+// SetRecurrence sets the Card's recurrence rule, starting from the current period; a nil rule stops the Card from recurring.
+// The Card's history is kept, in case it's made to recur again.
+func (card *Card) SetRecurrence(recurrence *Recurrence) {
+
+	if recurrence == nil {
+		card.Properties.Remove(RecurrenceProperty)
+		card.Properties.Remove(RecurrencePeriodProperty)
+		card.clearPropertyCache()
+		card.CreateUndoState = true
+		return
+	}
+
+	card.Properties.Get(RecurrencePeriodProperty).SetRaw(recurrence.PeriodStart(time.Now()).Format(time.RFC3339))
+	card.Properties.Get(RecurrenceProperty).Set(recurrence.String())
+
+}
+
+// RecurrenceHistory returns the outcomes of the Card's past periods, oldest first.
+func (card *Card) RecurrenceHistory() []RecurrencePeriod {
+
+	history := []RecurrencePeriod{}
+
+	if !card.Properties.Has(RecurrenceHistoryProperty) {
+		return history
+	}
+
+	prop := card.Properties.Props[RecurrenceHistoryProperty]
+	if !prop.IsString() {
+		return history
+	}
+
+	for _, period := range gjson.Parse(prop.AsString()).Array() {
+		history = append(history, RecurrencePeriod{
+			Start:     time.Unix(period.Get("start").Int(), 0),
+			Completed: period.Get("completed").Bool(),
+		})
+	}
+
+	return history
+
+}
+
+// Streak returns how many periods in a row the Card's been completed for, counting the current one if it's done already.
+func (card *Card) Streak() int {
+
+	// Only the past periods are cached, as the current one can be completed without the Card's Properties changing (i.e.
+	// through the Cards it depends on).
+	if !card.pastStreakCached {
+		card.pastStreak = 0
+		history := card.RecurrenceHistory()
+		for i := len(history) - 1; i >= 0 && history[i].Completed; i-- {
+			card.pastStreak++
+		}
+		card.pastStreakCached = true
+	}
+
+	streak := card.pastStreak
+
+	if card.Completed() {
+		streak++
+	}
+
+	return streak
+
+}
+
+// updateRecurrence resets a recurring Card once its period's over, logging whether it was completed to its history. If more
+// than one period's passed (i.e. the project was closed in the meantime), the ones in between count as missed.
+func (card *Card) updateRecurrence() {
+
+	if card.Page.Project.Loading || card.Page.Project.ReadOnly {
+		return
+	}
+
+	recurrence := card.Recurrence()
+	if recurrence == nil {
+		return
+	}
+
+	now := time.Now()
+
+	periodProp := card.Properties.Get(RecurrencePeriodProperty)
+
+	var period time.Time
+	validPeriod := false
+
+	if periodProp.IsString() {
+		t, err := time.Parse(time.RFC3339, periodProp.AsString())
+		period, validPeriod = t, err == nil
+	}
+
+	if !validPeriod {
+		periodProp.SetRaw(recurrence.PeriodStart(now).Format(time.RFC3339))
+		return
+	}
+
+	next := recurrence.NextPeriod(period)
+
+	if now.Before(next) {
+		return
+	}
+
+	data := "[]"
+	if prop := card.Properties.Get(RecurrenceHistoryProperty); prop.IsString() && prop.AsString() != "" {
+		data = prop.AsString()
+	}
+
+	completed := card.Completed()
+	periods := 0
+
+	for !now.Before(next) {
+
+		periodData, _ := sjson.Set("{}", "start", period.Unix())
+		periodData, _ = sjson.Set(periodData, "completed", completed && periods == 0)
+		data, _ = sjson.SetRaw(data, "-1", periodData)
+
+		period = next
+		next = recurrence.NextPeriod(period)
+		periods++
+
+		// No need to log every missed period if the project hasn't been opened in ages.
+		if periods >= RecurrenceHistoryLimit {
+			period = recurrence.PeriodStart(now)
+			break
+		}
+
+	}
+
+	if history := gjson.Parse(data).Array(); len(history) > RecurrenceHistoryLimit {
+		trimmed := "[]"
+		for _, entry := range history[len(history)-RecurrenceHistoryLimit:] {
+			trimmed, _ = sjson.SetRaw(trimmed, "-1", entry.Raw)
+		}
+		data = trimmed
+	}
+
+	card.Properties.Get(RecurrenceHistoryProperty).SetRaw(data)
+	periodProp.SetRaw(period.Format(time.RFC3339))
+	card.clearPropertyCache()
+
+	if card.ContentType == ContentTypeCheckbox {
+		card.Properties.Get("checked").Set(false)
+	} else if card.ContentType == ContentTypeNumbered {
+		card.Properties.Get("current").Set(0.0)
+	}
+
+	// Setting the properties above flags the Card for a new undo state, but the history has to be saved even if it was already
+	// reset.
+	card.CreateUndoState = true
+	card.Page.Project.Modified = true
+
+	if completed {
+		globals.EventLog.Log("Recurring task [%s] reset for a new period.", card.Properties.Get("description").AsString())
+	} else {
+		globals.EventLog.Log("Recurring task [%s] reset; it wasn't completed last period.", card.Properties.Get("description").AsString())
+	}
+
+}
+
+// RecurrenceSummary returns lines describing the Card's recurrence and recent history, for displaying.
+func (card *Card) RecurrenceSummary() []string {
+
+	recurrence := card.Recurrence()
+	if recurrence == nil {
+		return []string{}
+	}
+
+	lines := []string{
+		"Repeats: " + recurrence.String(),
+		fmt.Sprintf("Streak: %d", card.Streak()),
+	}
+
+	history := card.RecurrenceHistory()
+
+	for i := len(history) - 1; i >= 0 && i >= len(history)-5; i-- {
+		outcome := "Missed"
+		if history[i].Completed {
+			outcome = "Done"
+		}
+		lines = append(lines, "  "+history[i].Start.Format("Mon Jan 2 2006")+": "+outcome)
+	}
+
+	return lines
+
+}
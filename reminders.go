@@ -0,0 +1,258 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gen2brain/beeep"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// A Card's reminder time is stored in its Properties (in DeadlineFormat), along with whether it's gone off yet, so a reminder
+// that comes due while MasterPlan is closed goes off once the project's opened again.
+const (
+	ReminderProperty      = "reminder"
+	ReminderFiredProperty = "reminder fired"
+)
+
+// How long a reminder can be snoozed for, from the notification list.
+var ReminderSnoozeOptions = []struct {
+	Label    string
+	Duration time.Duration
+}{
+	{"10 Min", 10 * time.Minute},
+	{"1 Hour", time.Hour},
+	{"1 Day", 24 * time.Hour},
+}
+
+// A Clock tells the time. ReminderSchedulers are given one so they can be run against a time other than the current one.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (clock systemClock) Now() time.Time { return time.Now() }
+
+// ReminderTime returns when the Card's reminder is set to go off, if it has one.
+func (card *Card) ReminderTime() (time.Time, bool) {
+
+	if !card.Properties.Has(ReminderProperty) {
+		return time.Time{}, false
+	}
+
+	prop := card.Properties.Props[ReminderProperty]
+	if !prop.IsString() {
+		return time.Time{}, false
+	}
+
+	return ParseDeadline(prop.AsString())
+
+}
+
+// SetReminder sets the Card's reminder to go off at the given time.
+func (card *Card) SetReminder(t time.Time) {
+	card.Properties.Get(ReminderFiredProperty).SetRaw(false)
+	card.Properties.Get(ReminderProperty).Set(t.Format(DeadlineFormat))
+}
+
+func (card *Card) ClearReminder() {
+	card.Properties.Remove(ReminderProperty)
+	card.Properties.Remove(ReminderFiredProperty)
+	card.CreateUndoState = true
+}
+
+// ReminderSummary returns a line describing the Card's reminder, if it has one, for displaying.
+func (card *Card) ReminderSummary() []string {
+	if t, ok := card.ReminderTime(); ok {
+		return []string{"Reminder: " + t.Format(TimestampDisplayFormat)}
+	}
+	return []string{}
+}
+
+// A Reminder is a reminder that's gone off, waiting in the notification list to be dealt with.
+type Reminder struct {
+	Card    *Card
+	Time    time.Time
+	Message string
+}
+
+// ReminderScheduler sets off Cards' reminders once they come due, keeping a list of the ones that have gone off. It doesn't
+// alert the user itself; that's left to Notify and Log, which are optional.
+type ReminderScheduler struct {
+	Project   *Project
+	Clock     Clock
+	Notify    func(reminder *Reminder) // Called when a reminder goes off
+	Log       func(message string)     // Called with messages about what the scheduler's done
+	Reminders []*Reminder
+	Changed   bool // If the list of Reminders has changed since this was last reset
+
+	nextCheck time.Time
+}
+
+func NewReminderScheduler(project *Project, clock Clock) *ReminderScheduler {
+	return &ReminderScheduler{
+		Project: project,
+		Clock:   clock,
+	}
+}
+
+// Due returns those of the Cards whose reminders have come due, but haven't gone off yet.
+func (scheduler *ReminderScheduler) Due(cards []*Card) []*Card {
+
+	now := scheduler.Clock.Now()
+	due := []*Card{}
+
+	for _, card := range cards {
+		if t, ok := card.ReminderTime(); ok && !t.After(now) && !card.Properties.Get(ReminderFiredProperty).AsBool() {
+			due = append(due, card)
+		}
+	}
+
+	return due
+
+}
+
+func (scheduler *ReminderScheduler) Update() {
+
+	project := scheduler.Project
+
+	if project.Loading || project.ReadOnly {
+		return
+	}
+
+	// Reminders are only set to the minute, so there's no need to check every frame.
+	now := scheduler.Clock.Now()
+	if now.Before(scheduler.nextCheck) {
+		return
+	}
+	scheduler.nextCheck = now.Add(time.Second)
+
+	cards := []*Card{}
+	for _, page := range project.Pages {
+		if page.ReferenceCount <= 0 {
+			continue
+		}
+		for _, card := range page.Cards {
+			if card.Valid {
+				cards = append(cards, card)
+			}
+		}
+	}
+
+	for _, card := range scheduler.Due(cards) {
+
+		t, _ := card.ReminderTime()
+
+		card.Properties.Get(ReminderFiredProperty).SetRaw(true)
+		project.Modified = true
+
+		message := fmt.Sprintf("Reminder: %s (%s).", undoCardName(card), t.Format(TimestampDisplayFormat))
+		if now.Sub(t) > time.Minute {
+			message = fmt.Sprintf("Reminder: %s (%s, while MasterPlan was closed).", undoCardName(card), t.Format(TimestampDisplayFormat))
+		}
+
+		reminder := scheduler.Add(card, t, message)
+
+		if scheduler.Notify != nil {
+			scheduler.Notify(reminder)
+		}
+
+	}
+
+}
+
+// Add puts a Reminder for the Card in the notification list, returning it.
+func (scheduler *ReminderScheduler) Add(card *Card, t time.Time, message string) *Reminder {
+
+	// A Card only needs to be in the list once.
+	for _, reminder := range scheduler.Reminders {
+		if reminder.Card == card {
+			scheduler.Remove(reminder)
+			break
+		}
+	}
+
+	reminder := &Reminder{Card: card, Time: t, Message: message}
+	scheduler.Reminders = append(scheduler.Reminders, reminder)
+	scheduler.Changed = true
+
+	return reminder
+
+}
+
+// Remove takes the Reminder out of the notification list.
+func (scheduler *ReminderScheduler) Remove(reminder *Reminder) {
+	for i, r := range scheduler.Reminders {
+		if r == reminder {
+			scheduler.Reminders = append(scheduler.Reminders[:i], scheduler.Reminders[i+1:]...)
+			scheduler.Changed = true
+			break
+		}
+	}
+}
+
+// Snooze sets the Reminder's Card to remind the user again after the given amount of time.
+func (scheduler *ReminderScheduler) Snooze(reminder *Reminder, duration time.Duration) {
+
+	scheduler.Remove(reminder)
+
+	if !reminder.Card.Valid {
+		return
+	}
+
+	until := scheduler.Clock.Now().Add(duration)
+	reminder.Card.SetReminder(until)
+
+	scheduler.log(fmt.Sprintf("Reminder for %s snoozed until %s.", undoCardName(reminder.Card), until.Format(TimestampDisplayFormat)))
+
+}
+
+// Focus switches to the Reminder's Card, selecting it and centering the camera on it.
+func (scheduler *ReminderScheduler) Focus(reminder *Reminder) {
+
+	card := reminder.Card
+
+	if !card.Valid {
+		scheduler.log(fmt.Sprintf("Error: %s no longer exists.", undoCardName(card)))
+		return
+	}
+
+	scheduler.Project.SetPage(card.Page)
+
+	card.Page.Selection.Clear()
+	card.Page.Selection.Add(card)
+
+	scheduler.Project.Camera.FocusOn(false, card)
+
+}
+
+func (scheduler *ReminderScheduler) log(message string) {
+	if scheduler.Log != nil {
+		scheduler.Log(message)
+	}
+}
+
+var reminderAlarmSound *Sound
+
+// AlertReminder lets the user know a reminder's gone off with a desktop notification and the alarm sound, depending on their
+// settings; it's what a Project's ReminderScheduler notifies the user with. The notification list itself is opened regardless.
+func AlertReminder(reminder *Reminder) {
+
+	globals.EventLog.Log(reminder.Message)
+
+	globals.MenuSystem.Get("reminders").Open()
+
+	if globals.Settings.Get(SettingsNotifyOnReminders).AsBool() && globals.WindowFlags&sdl.WINDOW_INPUT_FOCUS == 0 {
+		beeep.Notify("MasterPlan", reminder.Message, "")
+	}
+
+	if globals.Settings.Get(SettingsRemindersPlayAlarmSound).AsBool() {
+		if reminderAlarmSound != nil {
+			reminderAlarmSound.Destroy()
+		}
+		reminderAlarmSound = globals.Resources.Get(LocalRelativePath("assets/alarm.wav")).AsNewSound()
+		reminderAlarmSound.Play()
+	}
+
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (clock *fakeClock) Now() time.Time { return clock.now }
+
+// newReminderTest returns a scheduler running on a fake clock, for a Project with a single Page holding the given Cards.
+// Reminders that go off are collected in notified.
+func newReminderTest(now time.Time, cards ...*Card) (*ReminderScheduler, *fakeClock, *[]*Reminder) {
+
+	page := &Page{ReferenceCount: 1, Cards: cards}
+	project := &Project{Pages: []*Page{page}}
+	page.Project = project
+
+	for _, card := range cards {
+		card.Page = page
+	}
+
+	clock := &fakeClock{now: now}
+	scheduler := NewReminderScheduler(project, clock)
+
+	notified := []*Reminder{}
+	scheduler.Notify = func(reminder *Reminder) {
+		notified = append(notified, reminder)
+	}
+
+	return scheduler, clock, &notified
+
+}
+
+func newReminderCard(description string) *Card {
+	card := &Card{Properties: NewProperties(), Valid: true, ContentType: ContentTypeCheckbox}
+	card.Properties.Get("description").Set(description)
+	return card
+}
+
+// Reminders are only set to the minute.
+var reminderTestNow = time.Date(2024, 3, 14, 9, 30, 0, 0, time.Local)
+
+func TestReminderDue(t *testing.T) {
+
+	past := newReminderCard("past")
+	past.SetReminder(reminderTestNow.Add(-time.Minute))
+
+	now := newReminderCard("now")
+	now.SetReminder(reminderTestNow)
+
+	future := newReminderCard("future")
+	future.SetReminder(reminderTestNow.Add(time.Minute))
+
+	fired := newReminderCard("fired")
+	fired.SetReminder(reminderTestNow.Add(-time.Hour))
+	fired.Properties.Get(ReminderFiredProperty).Set(true)
+
+	none := newReminderCard("none")
+
+	scheduler, _, _ := newReminderTest(reminderTestNow, past, now, future, fired, none)
+
+	due := scheduler.Due([]*Card{past, now, future, fired, none})
+
+	if len(due) != 2 || due[0] != past || due[1] != now {
+		t.Fatalf("expected the past and now Cards to be due, got %d Cards", len(due))
+	}
+
+}
+
+func TestReminderUpdateFiresOnce(t *testing.T) {
+
+	card := newReminderCard("water plants")
+	card.SetReminder(reminderTestNow.Add(time.Minute))
+
+	scheduler, clock, notified := newReminderTest(reminderTestNow, card)
+
+	scheduler.Update()
+
+	if len(*notified) != 0 || card.Properties.Get(ReminderFiredProperty).AsBool() {
+		t.Fatal("reminder went off before it was due")
+	}
+
+	clock.now = clock.now.Add(time.Minute)
+	scheduler.Update()
+
+	if len(*notified) != 1 || len(scheduler.Reminders) != 1 {
+		t.Fatalf("expected the reminder to go off once, went off %d times", len(*notified))
+	}
+
+	if !card.Properties.Get(ReminderFiredProperty).AsBool() {
+		t.Fatal("reminder wasn't marked as having gone off")
+	}
+
+	if !scheduler.Project.Modified {
+		t.Fatal("Project wasn't marked as modified after the reminder went off")
+	}
+
+	if strings.Contains((*notified)[0].Message, "closed") {
+		t.Fatalf("reminder that went off on time was reported as missed: %q", (*notified)[0].Message)
+	}
+
+	clock.now = clock.now.Add(time.Minute)
+	scheduler.Update()
+
+	if len(*notified) != 1 || len(scheduler.Reminders) != 1 {
+		t.Fatalf("reminder went off again after it was marked as having gone off (%d times)", len(*notified))
+	}
+
+}
+
+func TestReminderCatchUpWhileClosed(t *testing.T) {
+
+	card := newReminderCard("renew passport")
+	card.SetReminder(reminderTestNow.Add(-3 * time.Hour))
+
+	scheduler, _, notified := newReminderTest(reminderTestNow, card)
+
+	scheduler.Update()
+
+	if len(*notified) != 1 {
+		t.Fatalf("expected a missed reminder to go off once the project's opened, went off %d times", len(*notified))
+	}
+
+	if message := (*notified)[0].Message; !strings.Contains(message, "while MasterPlan was closed") {
+		t.Fatalf("missed reminder's message doesn't say it went off while MasterPlan was closed: %q", message)
+	}
+
+}
+
+func TestReminderSkippedWhileLoading(t *testing.T) {
+
+	card := newReminderCard("loading")
+	card.SetReminder(reminderTestNow)
+
+	scheduler, _, notified := newReminderTest(reminderTestNow, card)
+	scheduler.Project.Loading = true
+
+	scheduler.Update()
+
+	if len(*notified) != 0 {
+		t.Fatal("reminder went off while the project was loading")
+	}
+
+}
+
+func TestReminderSnooze(t *testing.T) {
+
+	card := newReminderCard("call back")
+	card.SetReminder(reminderTestNow)
+
+	scheduler, clock, notified := newReminderTest(reminderTestNow, card)
+
+	logged := []string{}
+	scheduler.Log = func(message string) { logged = append(logged, message) }
+
+	scheduler.Update()
+
+	if len(scheduler.Reminders) != 1 {
+		t.Fatalf("expected one reminder in the list, got %d", len(scheduler.Reminders))
+	}
+
+	scheduler.Changed = false
+	scheduler.Snooze(scheduler.Reminders[0], 10*time.Minute)
+
+	if len(scheduler.Reminders) != 0 || !scheduler.Changed {
+		t.Fatal("snoozed reminder wasn't taken out of the list")
+	}
+
+	if reminderTime, ok := card.ReminderTime(); !ok || !reminderTime.Equal(reminderTestNow.Add(10*time.Minute)) {
+		t.Fatalf("snoozed reminder set for %s, expected %s", reminderTime, reminderTestNow.Add(10*time.Minute))
+	}
+
+	if card.Properties.Get(ReminderFiredProperty).AsBool() {
+		t.Fatal("snoozed reminder is still marked as having gone off")
+	}
+
+	if len(logged) != 1 || !strings.Contains(logged[0], "snoozed") {
+		t.Fatalf("expected snoozing to be logged, got %q", logged)
+	}
+
+	clock.now = clock.now.Add(9 * time.Minute)
+	scheduler.Update()
+
+	if len(*notified) != 1 {
+		t.Fatal("snoozed reminder went off again too early")
+	}
+
+	clock.now = clock.now.Add(time.Minute)
+	scheduler.Update()
+
+	if len(*notified) != 2 || len(scheduler.Reminders) != 1 {
+		t.Fatalf("snoozed reminder didn't go off again after being snoozed (went off %d times)", len(*notified))
+	}
+
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"errors"
 	"image/gif"
 	"log"
 	"net/http"
@@ -9,6 +10,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/cavaliergopher/grab/v3"
 	"github.com/faiface/beep"
@@ -21,6 +23,11 @@ import (
 	"github.com/veandco/go-sdl2/sdl"
 )
 
+// ResourceCheckInterval is how often the files behind watched Resources are checked for changes.
+const ResourceCheckInterval = time.Second
+
+var lastResourceCheck = time.Time{}
+
 type ResourceBank map[string]*Resource
 
 func NewResourceBank() ResourceBank {
@@ -48,6 +55,46 @@ func (resourceBank ResourceBank) Get(resourcePath string) *Resource {
 
 }
 
+// Update reloads watched Resources whose files have been changed on disk since they were parsed.
+func (resourceBank ResourceBank) Update() {
+
+	// Reloaded GIFs are only swapped in once all of their frames have loaded, so the Cards showing them don't go blank.
+	for _, resource := range resourceBank {
+		if resource.pendingGIF != nil && resource.pendingGIF.IsReady() {
+			if resource.IsGIF() {
+				resource.AsGIF().Destroy()
+			} else if resource.IsTexture() {
+				resource.AsImage().Texture.Destroy()
+			}
+			resource.Data = resource.pendingGIF
+			resource.MimeType = resource.pendingMimeType
+			resource.pendingGIF = nil
+			resource.Version++
+		}
+	}
+
+	if time.Since(lastResourceCheck) < ResourceCheckInterval {
+		return
+	}
+
+	lastResourceCheck = time.Now()
+
+	for _, resource := range resourceBank {
+
+		// Downloaded and temporary files aren't going to be edited by anyone.
+		if !resource.Watched || !resource.Parsed || resource.Response != nil || resource.TempFile {
+			continue
+		}
+
+		if info, err := os.Stat(resource.LocalFilepath); err == nil && !info.ModTime().Equal(resource.ModTime) {
+			resource.ModTime = info.ModTime()
+			resource.Reload()
+		}
+
+	}
+
+}
+
 func (resourceBank ResourceBank) Destroy() {
 
 	for _, resource := range resourceBank {
@@ -65,6 +112,13 @@ type Resource struct {
 	Response      *grab.Response
 	TempFile      bool
 	Parsed        bool
+
+	// Watched Resources are reloaded when their files change on disk; Version goes up each time that happens.
+	Watched         bool
+	ModTime         time.Time
+	Version         int
+	pendingGIF      *GifAnimation
+	pendingMimeType string
 }
 
 func NewResource(resourcePath string) (*Resource, error) {
@@ -131,6 +185,10 @@ func (resource *Resource) Parse() {
 	mime, _ := mimetype.DetectFile(resource.LocalFilepath)
 	resource.MimeType = mime.String()
 
+	if info, err := os.Stat(resource.LocalFilepath); err == nil {
+		resource.ModTime = info.ModTime()
+	}
+
 	// if data, err := os.ReadFile(resource.LocalFilepath); err == nil {
 	// 	// We use mimetype because http.DetectContentType doesn't detect mp3 as being an audio file somehow
 	// 	resource.MimeType = mimetype.Detect(data).String()
@@ -140,52 +198,103 @@ func (resource *Resource) Parse() {
 
 	if isTGA || strings.Contains(resource.MimeType, "image") {
 
-		if strings.Contains(resource.MimeType, "gif") {
+		data, err := resource.loadImage(resource.MimeType)
+		if err != nil {
+			panic(err)
+		}
 
-			data, err := os.Open(resource.LocalFilepath)
+		resource.Data = data
 
-			if err != nil {
-				panic(err)
-			}
+	} else if strings.Contains(resource.MimeType, "audio") {
 
-			gifAnim, err := gif.DecodeAll(data)
+		// Sounds aren't shared, actually, so Resource.Data is nil for audio files.
 
-			if err != nil {
-				panic(err)
-			}
+	} else {
+		globals.EventLog.Log("Warning: could not parse resource: %s", resource.Name)
+	}
 
-			resource.Data = NewGifAnimation(gifAnim)
+	resource.Parsed = true
 
-		} else {
+}
 
-			surface, err := img.Load(resource.LocalFilepath)
-			if err != nil {
-				panic(err)
-			}
-			defer surface.Free()
+// loadImage loads the Resource's file as an Image, or as a GifAnimation if it's a GIF.
+func (resource *Resource) loadImage(mimeType string) (interface{}, error) {
 
-			texture, err := globals.Renderer.CreateTextureFromSurface(surface)
-			if err != nil {
-				panic(err)
-			}
-			texture.SetBlendMode(sdl.BLENDMODE_BLEND)
+	if strings.Contains(mimeType, "gif") {
 
-			resource.Data = Image{
-				Size:    Point{float32(surface.W), float32(surface.H)},
-				Texture: texture,
-			}
+		data, err := os.Open(resource.LocalFilepath)
+		if err != nil {
+			return nil, err
+		}
+
+		defer data.Close()
 
+		gifAnim, err := gif.DecodeAll(data)
+		if err != nil {
+			return nil, err
 		}
 
-	} else if strings.Contains(resource.MimeType, "audio") {
+		return NewGifAnimation(gifAnim), nil
 
-		// Sounds aren't shared, actually, so Resource.Data is nil for audio files.
+	}
 
-	} else {
-		globals.EventLog.Log("Warning: could not parse resource: %s", resource.Name)
+	surface, err := img.Load(resource.LocalFilepath)
+	if err != nil {
+		return nil, err
 	}
+	defer surface.Free()
 
-	resource.Parsed = true
+	texture, err := globals.Renderer.CreateTextureFromSurface(surface)
+	if err != nil {
+		return nil, err
+	}
+	texture.SetBlendMode(sdl.BLENDMODE_BLEND)
+
+	return Image{
+		Size:    Point{float32(surface.W), float32(surface.H)},
+		Texture: texture,
+	}, nil
+
+}
+
+// Reload parses the Resource's file again after it's been changed on disk. If the file can't be parsed (for example, because
+// it's still being written), the Resource keeps its current data.
+func (resource *Resource) Reload() {
+
+	mime, _ := mimetype.DetectFile(resource.LocalFilepath)
+	mimeType := mime.String()
+
+	if strings.Contains(mimeType, "audio") {
+		// Sounds are created fresh for each Card from the file, so there's nothing to swap out here.
+		resource.MimeType = mimeType
+		resource.Version++
+		return
+	}
+
+	if resource.Extension != ".tga" && !strings.Contains(mimeType, "image") {
+		globals.EventLog.Log("Warning: could not reload resource: %s", resource.Name)
+		return
+	}
+
+	data, err := resource.loadImage(mimeType)
+	if err != nil {
+		globals.EventLog.Log("Error: Couldn't reload [%s]: %s", resource.Name, err.Error())
+		return
+	}
+
+	if gifAnim, isGIF := data.(*GifAnimation); isGIF {
+		resource.pendingGIF = gifAnim
+		resource.pendingMimeType = mimeType
+		return
+	}
+
+	if resource.IsTexture() {
+		resource.AsImage().Texture.Destroy()
+	}
+
+	resource.Data = data
+	resource.MimeType = mimeType
+	resource.Version++
 
 }
 
@@ -244,11 +353,23 @@ func (resource *Resource) IsSound() bool {
 
 func (resource *Resource) AsNewSound() *Sound {
 
-	originalFile, err := os.Open(resource.LocalFilepath)
+	sound, err := resource.NewSound()
 	if err != nil {
 		panic(err)
 	}
 
+	return sound
+
+}
+
+// NewSound creates a new Sound from the Resource's file.
+func (resource *Resource) NewSound() (*Sound, error) {
+
+	originalFile, err := os.Open(resource.LocalFilepath)
+	if err != nil {
+		return nil, err
+	}
+
 	var originalStream beep.StreamSeekCloser
 	var format beep.Format
 
@@ -263,10 +384,16 @@ func (resource *Resource) AsNewSound() *Sound {
 	}
 
 	if err != nil {
-		panic(err)
+		originalFile.Close()
+		return nil, err
+	}
+
+	if originalStream == nil {
+		originalFile.Close()
+		return nil, errors.New("unsupported audio format: " + resource.MimeType)
 	}
 
-	return NewSound(originalStream, format)
+	return NewSound(originalStream, format), nil
 }
 
 func (resource *Resource) Destroy() {
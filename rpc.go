@@ -0,0 +1,622 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// The automation server speaks JSON-RPC 2.0, one request or response per line, over a localhost TCP port or a Unix domain
+// socket (if the address starts with "unix:"). Requests are read on the connection's goroutine, but are handed off to be
+// run on the main loop, so they can safely touch the Project and are recorded in its UndoHistory like any other change.
+//
+// Each connection has to start with an "auth" request carrying the token from the program settings; the connection's closed
+// on anything else, or on the first line that isn't a valid JSON-RPC request (like a web page trying to talk to the server
+// over HTTP).
+
+const (
+	RPCDefaultAddress = "localhost:47650"
+
+	rpcErrorParse          = -32700
+	rpcErrorInvalidRequest = -32600
+	rpcErrorMethodNotFound = -32601
+	rpcErrorInvalidParams  = -32602
+	rpcErrorServer         = -32000
+	rpcErrorUnauthorized   = -32001
+)
+
+type RPCRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (err *RPCError) Error() string {
+	return fmt.Sprintf("%s (%d)", err.Message, err.Code)
+}
+
+// A response's ID is always included; it's null if the request's ID couldn't be read.
+type RPCResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+type rpcCall struct {
+	Request *RPCRequest
+	Reply   chan *RPCResponse
+}
+
+type RPCServer struct {
+	Address  string
+	token    string
+	listener net.Listener
+	calls    chan *rpcCall
+}
+
+func NewRPCServer() *RPCServer {
+	return &RPCServer{
+		calls: make(chan *rpcCall, 64),
+	}
+}
+
+// rpcListenAddress splits an address setting into the network and address to listen on or dial.
+func rpcListenAddress(address string) (string, string) {
+	if strings.HasPrefix(address, "unix:") {
+		return "unix", strings.TrimPrefix(address, "unix:")
+	}
+	return "tcp", address
+}
+
+// Start starts listening on the address set in the program settings; if the server was already running, it's restarted.
+func (server *RPCServer) Start() {
+
+	server.Stop()
+
+	server.Address = globals.Settings.Get(SettingsAutomationServerAddress).AsString()
+	if server.Address == "" {
+		server.Address = RPCDefaultAddress
+	}
+
+	// Connections have to present the token before they can make any calls, so the server gets one the first time it's started.
+	if globals.Settings.Get(SettingsAutomationServerToken).AsString() == "" {
+		globals.Settings.Get(SettingsAutomationServerToken).Set(NewRPCToken())
+	}
+
+	server.token = globals.Settings.Get(SettingsAutomationServerToken).AsString()
+
+	network, address := rpcListenAddress(server.Address)
+
+	if network == "tcp" {
+		// Only accept local connections; anything that can reach the server can edit the project.
+		if host, _, err := net.SplitHostPort(address); err != nil || (host != "localhost" && !net.ParseIP(host).IsLoopback()) {
+			globals.EventLog.Log("Error: The automation server can only listen on localhost, not [%s].", address)
+			return
+		}
+	} else {
+		// Clean up a socket left behind by a previous session that didn't shut down cleanly.
+		if conn, err := net.Dial(network, address); err == nil {
+			conn.Close()
+		} else {
+			os.Remove(address)
+		}
+	}
+
+	listener, err := net.Listen(network, address)
+	if err != nil {
+		globals.EventLog.Log("Error: Couldn't start automation server: %s", err.Error())
+		return
+	}
+
+	server.listener = listener
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.handleConnection(conn)
+		}
+	}()
+
+	globals.EventLog.Log("Automation server listening on [%s].", server.Address)
+
+}
+
+func (server *RPCServer) Stop() {
+	if server.listener != nil {
+		server.listener.Close()
+		server.listener = nil
+		globals.EventLog.Log("Automation server stopped.")
+	}
+}
+
+func (server *RPCServer) Running() bool {
+	return server.listener != nil
+}
+
+func (server *RPCServer) handleConnection(conn net.Conn) {
+
+	defer conn.Close()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	encoder := json.NewEncoder(conn)
+
+	authorized := false
+
+	for scanner.Scan() {
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		// There's nothing to say to an HTTP client (i.e. a browser that's been pointed at the server), so it's just hung up on.
+		if rpcLooksLikeHTTP(line) {
+			return
+		}
+
+		request := &RPCRequest{}
+
+		if err := json.Unmarshal([]byte(line), request); err != nil {
+			encoder.Encode(&RPCResponse{JSONRPC: "2.0", Error: &RPCError{rpcErrorParse, err.Error()}})
+			return
+		}
+
+		if request.JSONRPC != "2.0" || request.Method == "" {
+			encoder.Encode(&RPCResponse{JSONRPC: "2.0", ID: request.ID, Error: &RPCError{rpcErrorInvalidRequest, "invalid JSON-RPC 2.0 request"}})
+			return
+		}
+
+		// Requests without an ID are notifications, which are run, but never replied to.
+		notification := len(request.ID) == 0
+
+		if !authorized {
+
+			if request.Method != "auth" || !server.checkToken(request.Params) {
+				if !notification {
+					encoder.Encode(&RPCResponse{JSONRPC: "2.0", ID: request.ID, Error: &RPCError{rpcErrorUnauthorized, "unauthorized; send an auth request with the token from MasterPlan's settings first"}})
+				}
+				return
+			}
+
+			authorized = true
+
+			if !notification {
+				if err := encoder.Encode(&RPCResponse{JSONRPC: "2.0", ID: request.ID, Result: true}); err != nil {
+					return
+				}
+			}
+
+			continue
+
+		}
+
+		call := &rpcCall{Request: request, Reply: make(chan *RPCResponse, 1)}
+		server.calls <- call
+
+		response := <-call.Reply
+
+		if notification {
+			continue
+		}
+
+		if err := encoder.Encode(response); err != nil {
+			return
+		}
+
+	}
+
+}
+
+// checkToken returns if an auth request's parameters hold the server's token.
+func (server *RPCServer) checkToken(params json.RawMessage) bool {
+
+	auth := struct {
+		Token string `json:"token"`
+	}{}
+
+	if err := json.Unmarshal(params, &auth); err != nil || server.token == "" {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(auth.Token), []byte(server.token)) == 1
+
+}
+
+// rpcLooksLikeHTTP returns if a line looks like the start of an HTTP request.
+func rpcLooksLikeHTTP(line string) bool {
+
+	for _, method := range []string{"GET", "HEAD", "POST", "PUT", "DELETE", "CONNECT", "OPTIONS", "TRACE", "PATCH"} {
+		if strings.HasPrefix(line, method+" ") {
+			return true
+		}
+	}
+
+	return strings.Contains(line, " HTTP/")
+
+}
+
+// NewRPCToken returns a new random token for the automation server.
+func NewRPCToken() string {
+	data := make([]byte, 16)
+	rand.Read(data)
+	return hex.EncodeToString(data)
+}
+
+// Update runs the requests that have come in since the last frame; it should be called from the main loop, before the Project
+// updates, so that any changes are captured in this frame's undo state.
+func (server *RPCServer) Update() {
+
+	for {
+
+		select {
+
+		case call := <-server.calls:
+
+			response := &RPCResponse{JSONRPC: "2.0", ID: call.Request.ID}
+
+			if result, err := server.run(call.Request); err != nil {
+				if rpcErr, ok := err.(*RPCError); ok {
+					response.Error = rpcErr
+				} else {
+					response.Error = &RPCError{rpcErrorServer, err.Error()}
+				}
+			} else {
+				response.Result = result
+			}
+
+			call.Reply <- response
+
+		default:
+			return
+
+		}
+
+	}
+
+}
+
+type rpcPageInfo struct {
+	ID    uint64 `json:"id"`
+	Name  string `json:"name"`
+	Cards int    `json:"cards"`
+}
+
+func (server *RPCServer) run(request *RPCRequest) (interface{}, error) {
+
+	project := globals.Project
+
+	params := struct {
+		Page       *uint64                `json:"page"`
+		ID         *int64                 `json:"id"`
+		Type       string                 `json:"type"`
+		X          *float32               `json:"x"`
+		Y          *float32               `json:"y"`
+		Properties map[string]interface{} `json:"properties"`
+		Trigger    string                 `json:"trigger"`
+	}{}
+
+	if len(request.Params) > 0 {
+		if err := json.Unmarshal(request.Params, &params); err != nil {
+			return nil, &RPCError{rpcErrorInvalidParams, err.Error()}
+		}
+	}
+
+	page := project.CurrentPage
+	if params.Page != nil {
+		page = nil
+		for _, p := range project.Pages {
+			if p.ID == *params.Page {
+				page = p
+				break
+			}
+		}
+		if page == nil {
+			return nil, &RPCError{rpcErrorInvalidParams, fmt.Sprintf("no page with id %d", *params.Page)}
+		}
+	}
+
+	findCard := func() (*Card, error) {
+		if params.ID == nil {
+			return nil, &RPCError{rpcErrorInvalidParams, "missing card id"}
+		}
+		for _, p := range project.Pages {
+			if card := p.CardByID(*params.ID); card != nil && card.Valid {
+				return card, nil
+			}
+		}
+		return nil, &RPCError{rpcErrorInvalidParams, fmt.Sprintf("no card with id %d", *params.ID)}
+	}
+
+	switch request.Method {
+
+	case "pages.list":
+
+		pages := []rpcPageInfo{}
+		for _, p := range project.Pages {
+			pages = append(pages, rpcPageInfo{ID: p.ID, Name: p.Name, Cards: len(p.Cards)})
+		}
+		return pages, nil
+
+	case "cards.list":
+
+		cards := []json.RawMessage{}
+		for _, card := range page.Cards {
+			if card.Valid {
+				cards = append(cards, json.RawMessage(card.Serialize()))
+			}
+		}
+		return cards, nil
+
+	case "card.get":
+
+		card, err := findCard()
+		if err != nil {
+			return nil, err
+		}
+		return json.RawMessage(card.Serialize()), nil
+
+	case "card.set":
+
+		card, err := findCard()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := rpcSetProperties(card, params.Properties); err != nil {
+			return nil, err
+		}
+
+		rpcCaptureUndo(card)
+
+		return json.RawMessage(card.Serialize()), nil
+
+	case "card.create":
+
+		contentType := params.Type
+		if contentType == "" {
+			contentType = ContentTypeCheckbox
+		}
+
+		valid := false
+		for _, t := range ScriptableContentTypes {
+			if t == contentType {
+				valid = true
+				break
+			}
+		}
+
+		if !valid {
+			return nil, &RPCError{rpcErrorInvalidParams, fmt.Sprintf("unknown card type %q", contentType)}
+		}
+
+		card := page.CreateNewCard(contentType)
+
+		if params.X != nil {
+			card.Rect.X = *params.X
+		}
+		if params.Y != nil {
+			card.Rect.Y = *params.Y
+		}
+		card.LockPosition()
+
+		if err := rpcSetProperties(card, params.Properties); err != nil {
+			return nil, err
+		}
+
+		rpcCaptureUndo(card)
+
+		return json.RawMessage(card.Serialize()), nil
+
+	case "card.delete":
+
+		card, err := findCard()
+		if err != nil {
+			return nil, err
+		}
+
+		card.Page.DeleteCards(card)
+
+		return true, nil
+
+	case "card.trigger":
+
+		card, err := findCard()
+		if err != nil {
+			return nil, err
+		}
+
+		triggerType := TriggerTypeToggle
+
+		switch strings.ToLower(params.Trigger) {
+		case "", "toggle":
+		case "set":
+			triggerType = TriggerTypeSet
+		case "clear":
+			triggerType = TriggerTypeClear
+		default:
+			return nil, &RPCError{rpcErrorInvalidParams, fmt.Sprintf("unknown trigger %q; use set, clear, or toggle", params.Trigger)}
+		}
+
+		card.Contents.Trigger(triggerType)
+
+		rpcCaptureUndo(card)
+
+		return json.RawMessage(card.Serialize()), nil
+
+	case "project.save":
+
+		if project.Filepath == "" {
+			return nil, errors.New("project hasn't been saved to a file yet")
+		}
+
+		if project.ChangedOnDisk() {
+			return nil, errors.New("project file was changed on disk since it was loaded; save from MasterPlan to confirm overwriting it")
+		}
+
+		project.Save()
+
+		return project.Filepath, nil
+
+	}
+
+	return nil, &RPCError{rpcErrorMethodNotFound, fmt.Sprintf("unknown method %q", request.Method)}
+
+}
+
+func rpcSetProperties(card *Card, properties map[string]interface{}) error {
+
+	for name, value := range properties {
+
+		switch value.(type) {
+		case string, float64, bool:
+			card.Properties.Get(name).Set(value)
+		default:
+			return &RPCError{rpcErrorInvalidParams, fmt.Sprintf("property %q must be a string, number, or boolean", name)}
+		}
+
+	}
+
+	return nil
+
+}
+
+// rpcCaptureUndo captures the Card's state straight away, as Cards on pages that aren't being displayed don't update (and
+// so wouldn't capture the change themselves).
+func rpcCaptureUndo(card *Card) {
+	card.captureChange()
+	card.CreateUndoState = false
+}
+
+// RunRPCClient implements the "masterplan rpc" command, sending a single request to a running MasterPlan's automation server
+// and printing the result. Parameters can be passed as a JSON object, or as key=value pairs (where values are parsed as JSON if
+// possible, and used as strings otherwise). It returns the exit code for the process.
+func RunRPCClient(args []string) int {
+
+	address := globals.Settings.Get(SettingsAutomationServerAddress).AsString()
+	token := globals.Settings.Get(SettingsAutomationServerToken).AsString()
+
+	for len(args) >= 2 && (args[0] == "-address" || args[0] == "-token") {
+		if args[0] == "-address" {
+			address = args[1]
+		} else {
+			token = args[1]
+		}
+		args = args[2:]
+	}
+
+	if address == "" {
+		address = RPCDefaultAddress
+	}
+
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: masterplan rpc [-address addr] [-token token] <method> [{json params} | key=value ...]")
+		fmt.Fprintln(os.Stderr, "methods: pages.list, cards.list, card.get, card.set, card.create, card.delete, card.trigger, project.save")
+		return 2
+	}
+
+	request := &RPCRequest{JSONRPC: "2.0", ID: json.RawMessage("1"), Method: args[0]}
+
+	if len(args) == 2 && strings.HasPrefix(strings.TrimSpace(args[1]), "{") {
+		request.Params = json.RawMessage(args[1])
+	} else if len(args) > 1 {
+
+		params := map[string]interface{}{}
+		properties := map[string]interface{}{}
+
+		for _, arg := range args[1:] {
+
+			parts := strings.SplitN(arg, "=", 2)
+			if len(parts) != 2 {
+				fmt.Fprintf(os.Stderr, "error: argument %q isn't of the form key=value\n", arg)
+				return 2
+			}
+
+			var value interface{}
+			if err := json.Unmarshal([]byte(parts[1]), &value); err != nil {
+				value = parts[1]
+			}
+
+			// Anything that isn't a known parameter is taken to be a card property, so "card.set id=4 checked=true" works.
+			switch parts[0] {
+			case "page", "id", "type", "x", "y", "trigger":
+				params[parts[0]] = value
+			default:
+				properties[parts[0]] = value
+			}
+
+		}
+
+		if len(properties) > 0 {
+			params["properties"] = properties
+		}
+
+		request.Params, _ = json.Marshal(params)
+
+	}
+
+	network, dialAddress := rpcListenAddress(address)
+
+	conn, err := net.DialTimeout(network, dialAddress, time.Second*5)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: couldn't connect to MasterPlan at %s; is the automation server enabled? (%s)\n", address, err.Error())
+		return 1
+	}
+
+	defer conn.Close()
+
+	encoder := json.NewEncoder(conn)
+	decoder := json.NewDecoder(bufio.NewReader(conn))
+
+	authParams, _ := json.Marshal(map[string]string{"token": token})
+	auth := &RPCRequest{JSONRPC: "2.0", ID: json.RawMessage("0"), Method: "auth", Params: authParams}
+
+	result := json.RawMessage{}
+
+	for _, req := range []*RPCRequest{auth, request} {
+
+		if err := encoder.Encode(req); err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err.Error())
+			return 1
+		}
+
+		result = json.RawMessage{}
+		response := &RPCResponse{Result: &result}
+
+		if err := decoder.Decode(response); err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err.Error())
+			return 1
+		}
+
+		if response.Error != nil {
+			fmt.Fprintln(os.Stderr, "error:", response.Error.Message)
+			return 1
+		}
+
+	}
+
+	out, _ := json.MarshalIndent(result, "", "  ")
+	fmt.Println(string(out))
+
+	return 0
+
+}
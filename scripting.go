@@ -0,0 +1,597 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"go.starlark.net/starlark"
+)
+
+// ScriptFolder is the folder, next to the project file, that a project's scripts are loaded from.
+const ScriptFolder = "scripts"
+
+// ScriptMaxSteps caps how many steps a script (or a single message handler) can take, so a runaway loop can't hang MasterPlan.
+const ScriptMaxSteps = 10000000
+
+// ScriptableContentTypes are the types of Cards scripts can create.
+var ScriptableContentTypes = []string{
+	ContentTypeCheckbox,
+	ContentTypeNumbered,
+	ContentTypeNote,
+	ContentTypeSound,
+	ContentTypeImage,
+	ContentTypeTimer,
+	ContentTypeMap,
+	ContentTypeSubpage,
+	ContentTypeFrame,
+}
+
+type scriptHandler struct {
+	Script   string
+	Function starlark.Callable
+}
+
+// ScriptEngine runs Starlark scripts against a Project. Scripts are sandboxed: they can't touch the filesystem or network, and
+// can only affect the Project through the values and builtins handed to them.
+type ScriptEngine struct {
+	Project    *Project
+	Handlers   map[string][]scriptHandler
+	LastScript string
+
+	dispatching bool
+}
+
+func NewScriptEngine(project *Project) *ScriptEngine {
+	return &ScriptEngine{
+		Project:  project,
+		Handlers: map[string][]scriptHandler{},
+	}
+}
+
+// Directory returns the Project's script folder, or an empty string if the Project hasn't been saved yet.
+func (engine *ScriptEngine) Directory() string {
+	if engine.Project.Filepath == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(engine.Project.Filepath), ScriptFolder)
+}
+
+// Scripts returns the paths of the scripts in the Project's script folder.
+func (engine *ScriptEngine) Scripts() []string {
+
+	scripts := []string{}
+
+	dir := engine.Directory()
+	if dir == "" {
+		return scripts
+	}
+
+	files, _ := os.ReadDir(dir)
+
+	for _, file := range files {
+		if !file.IsDir() && filepath.Ext(file.Name()) == ".star" {
+			scripts = append(scripts, filepath.Join(dir, file.Name()))
+		}
+	}
+
+	sort.Strings(scripts)
+
+	return scripts
+
+}
+
+// CreateDirectory creates the Project's script folder, copying the bundled example scripts into it.
+func (engine *ScriptEngine) CreateDirectory() error {
+
+	dir := engine.Directory()
+	if dir == "" {
+		return errors.New("project must be saved before it can have scripts")
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	examples, _ := os.ReadDir(LocalRelativePath("assets/scripts"))
+
+	for _, example := range examples {
+
+		dest := filepath.Join(dir, example.Name())
+
+		if example.IsDir() || FileExists(dest) {
+			continue
+		}
+
+		if data, err := os.ReadFile(LocalRelativePath(filepath.Join("assets/scripts", example.Name()))); err == nil {
+			os.WriteFile(dest, data, 0644)
+		}
+
+	}
+
+	return nil
+
+}
+
+func (engine *ScriptEngine) newThread(scriptName string) *starlark.Thread {
+
+	thread := &starlark.Thread{
+		Name: scriptName,
+		Print: func(thread *starlark.Thread, msg string) {
+			globals.EventLog.Log("%s: %s", thread.Name, msg)
+		},
+	}
+
+	thread.SetMaxExecutionSteps(ScriptMaxSteps)
+
+	return thread
+
+}
+
+// Run runs the script at the given path. Message handlers the script registered on a previous run are replaced.
+func (engine *ScriptEngine) Run(scriptPath string) {
+
+	scriptName := filepath.Base(scriptPath)
+
+	engine.LastScript = scriptPath
+
+	src, err := os.ReadFile(scriptPath)
+	if err != nil {
+		globals.EventLog.Log("Error: Couldn't read script [%s]: %s", scriptName, err.Error())
+		return
+	}
+
+	engine.RemoveHandlers(scriptName)
+
+	thread := engine.newThread(scriptName)
+
+	if _, err := starlark.ExecFile(thread, scriptName, src, engine.predeclared(scriptName)); err != nil {
+		engine.logError(scriptName, err)
+		return
+	}
+
+	globals.EventLog.Log("Script [%s] ran successfully.", scriptName)
+
+}
+
+// RemoveHandlers removes the message handlers registered by the named script, or every handler if the name is empty.
+func (engine *ScriptEngine) RemoveHandlers(scriptName string) {
+
+	for messageType, handlers := range engine.Handlers {
+
+		kept := []scriptHandler{}
+
+		for _, handler := range handlers {
+			if scriptName != "" && handler.Script != scriptName {
+				kept = append(kept, handler)
+			}
+		}
+
+		engine.Handlers[messageType] = kept
+
+	}
+
+}
+
+// ReceiveMessage passes a Message received by a Card to the script handlers subscribed to its type.
+func (engine *ScriptEngine) ReceiveMessage(card *Card, msg *Message) {
+
+	// Messages caused by the handlers themselves aren't passed back in, as that could easily loop forever.
+	if engine == nil || engine.dispatching || len(engine.Handlers[msg.Type]) == 0 {
+		return
+	}
+
+	engine.dispatching = true
+
+	for _, handler := range engine.Handlers[msg.Type] {
+
+		thread := engine.newThread(handler.Script)
+
+		if _, err := starlark.Call(thread, handler.Function, starlark.Tuple{newScriptCard(card), starlark.String(strings.TrimPrefix(msg.Type, "Message"))}, nil); err != nil {
+			engine.logError(handler.Script, err)
+		}
+
+	}
+
+	engine.dispatching = false
+
+}
+
+func (engine *ScriptEngine) logError(scriptName string, err error) {
+	if evalErr, ok := err.(*starlark.EvalError); ok {
+		globals.EventLog.Log("Error in script [%s]:\n%s", scriptName, evalErr.Backtrace())
+	} else {
+		globals.EventLog.Log("Error in script [%s]: %s", scriptName, err.Error())
+	}
+}
+
+// predeclared returns the values and builtins available to scripts.
+func (engine *ScriptEngine) predeclared(scriptName string) starlark.StringDict {
+
+	return starlark.StringDict{
+
+		"project": &scriptProject{Project: engine.Project},
+
+		"content_types": starlarkStrings(ScriptableContentTypes),
+
+		"log": starlark.NewBuiltin("log", func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			text := []string{}
+			for _, arg := range args {
+				if s, ok := starlark.AsString(arg); ok {
+					text = append(text, s)
+				} else {
+					text = append(text, arg.String())
+				}
+			}
+			globals.EventLog.Log("%s: %s", scriptName, strings.Join(text, " "))
+			return starlark.None, nil
+		}),
+
+		// on(message, function) calls function(card, message) whenever a Card receives a Message of the given type (e.g.
+		// "LinkCreated" or "CardDeleted").
+		"on": starlark.NewBuiltin("on", func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+
+			var messageType string
+			var function starlark.Callable
+
+			if err := starlark.UnpackPositionalArgs(fn.Name(), args, kwargs, 2, &messageType, &function); err != nil {
+				return nil, err
+			}
+
+			if !strings.HasPrefix(messageType, "Message") {
+				messageType = "Message" + messageType
+			}
+
+			engine.Handlers[messageType] = append(engine.Handlers[messageType], scriptHandler{Script: scriptName, Function: function})
+
+			return starlark.None, nil
+
+		}),
+	}
+
+}
+
+func starlarkStrings(values []string) *starlark.List {
+	list := []starlark.Value{}
+	for _, v := range values {
+		list = append(list, starlark.String(v))
+	}
+	return starlark.NewList(list)
+}
+
+// Project
+
+type scriptProject struct {
+	Project *Project
+}
+
+func (sp *scriptProject) String() string        { return fmt.Sprintf("<project %s>", sp.Project.Filepath) }
+func (sp *scriptProject) Type() string          { return "project" }
+func (sp *scriptProject) Freeze()               {}
+func (sp *scriptProject) Truth() starlark.Bool  { return starlark.True }
+func (sp *scriptProject) Hash() (uint32, error) { return 0, errors.New("unhashable type: project") }
+
+func (sp *scriptProject) AttrNames() []string {
+	return []string{"current_page", "filepath", "pages", "tags"}
+}
+
+func (sp *scriptProject) Attr(name string) (starlark.Value, error) {
+
+	switch name {
+	case "current_page":
+		return &scriptPage{Page: sp.Project.CurrentPage}, nil
+	case "filepath":
+		return starlark.String(sp.Project.Filepath), nil
+	case "pages":
+		pages := []starlark.Value{}
+		for _, page := range sp.Project.Pages {
+			pages = append(pages, &scriptPage{Page: page})
+		}
+		return starlark.NewList(pages), nil
+	case "tags":
+		return starlarkStrings(sp.Project.Tags()), nil
+	}
+
+	return nil, nil
+
+}
+
+// Page
+
+type scriptPage struct {
+	Page *Page
+}
+
+func (sp *scriptPage) String() string        { return fmt.Sprintf("<page %s>", sp.Page.Name) }
+func (sp *scriptPage) Type() string          { return "page" }
+func (sp *scriptPage) Freeze()               {}
+func (sp *scriptPage) Truth() starlark.Bool  { return starlark.True }
+func (sp *scriptPage) Hash() (uint32, error) { return uint32(sp.Page.ID), nil }
+
+func (sp *scriptPage) AttrNames() []string {
+	return []string{"cards", "create_card", "id", "name", "selection"}
+}
+
+func (sp *scriptPage) Attr(name string) (starlark.Value, error) {
+
+	switch name {
+
+	case "id":
+		return starlark.MakeUint64(sp.Page.ID), nil
+
+	case "name":
+		return starlark.String(sp.Page.Name), nil
+
+	case "cards":
+		cards := []starlark.Value{}
+		for _, card := range sp.Page.Cards {
+			if card.Valid {
+				cards = append(cards, newScriptCard(card))
+			}
+		}
+		return starlark.NewList(cards), nil
+
+	case "selection":
+		cards := []starlark.Value{}
+		for _, card := range sp.Page.Selection.AsSlice() {
+			cards = append(cards, newScriptCard(card))
+		}
+		return starlark.NewList(cards), nil
+
+	// create_card(type, x=0, y=0, text="") creates a new Card of the given type on the Page and returns it.
+	case "create_card":
+		return starlark.NewBuiltin("create_card", func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+
+			var contentType, text string
+			var x, y float64
+
+			if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "type", &contentType, "x?", &x, "y?", &y, "text?", &text); err != nil {
+				return nil, err
+			}
+
+			valid := false
+			for _, t := range ScriptableContentTypes {
+				if t == contentType {
+					valid = true
+					break
+				}
+			}
+
+			if !valid {
+				return nil, fmt.Errorf("create_card: unknown card type %q", contentType)
+			}
+
+			card := sp.Page.CreateNewCard(contentType)
+			card.Rect.X = float32(x)
+			card.Rect.Y = float32(y)
+			card.LockPosition()
+
+			if text != "" {
+				card.Properties.Get("description").Set(text)
+			}
+
+			return newScriptCard(card), nil
+
+		}).BindReceiver(sp), nil
+
+	}
+
+	return nil, nil
+
+}
+
+// Card
+
+type scriptCard struct {
+	Card *Card
+}
+
+func newScriptCard(card *Card) *scriptCard {
+	return &scriptCard{Card: card}
+}
+
+func (sc *scriptCard) String() string {
+	return fmt.Sprintf("<card %d (%s)>", sc.Card.ID, sc.Card.ContentType)
+}
+func (sc *scriptCard) Type() string          { return "card" }
+func (sc *scriptCard) Freeze()               {}
+func (sc *scriptCard) Truth() starlark.Bool  { return starlark.Bool(sc.Card.Valid) }
+func (sc *scriptCard) Hash() (uint32, error) { return uint32(sc.Card.ID), nil }
+
+func (sc *scriptCard) AttrNames() []string {
+	return []string{"completed", "delete", "get", "h", "has", "id", "link", "links", "page", "selected", "set", "set_tags", "set_type", "tags", "type", "unlink", "w", "x", "y"}
+}
+
+func (sc *scriptCard) Attr(name string) (starlark.Value, error) {
+
+	card := sc.Card
+
+	switch name {
+
+	case "id":
+		return starlark.MakeInt64(card.ID), nil
+	case "type":
+		return starlark.String(card.ContentType), nil
+	case "x":
+		return starlark.Float(card.Rect.X), nil
+	case "y":
+		return starlark.Float(card.Rect.Y), nil
+	case "w":
+		return starlark.Float(card.Rect.W), nil
+	case "h":
+		return starlark.Float(card.Rect.H), nil
+	case "page":
+		return &scriptPage{Page: card.Page}, nil
+	case "tags":
+		return starlarkStrings(card.Tags()), nil
+	case "links":
+		linked := []starlark.Value{}
+		for _, link := range card.Links {
+			if link.Start == card {
+				linked = append(linked, newScriptCard(link.End))
+			} else {
+				linked = append(linked, newScriptCard(link.Start))
+			}
+		}
+		return starlark.NewList(linked), nil
+	case "selected":
+		return starlark.Bool(card.selected), nil
+	case "completed":
+		return starlark.Bool(card.Completed()), nil
+
+	// get(name) returns the value of the named property, or None if the Card doesn't have it.
+	case "get":
+		return starlark.NewBuiltin("get", func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			var propName string
+			if err := starlark.UnpackPositionalArgs(fn.Name(), args, kwargs, 1, &propName); err != nil {
+				return nil, err
+			}
+			if !card.Properties.Has(propName) {
+				return starlark.None, nil
+			}
+			return propertyToStarlark(card.Properties.Get(propName)), nil
+		}).BindReceiver(sc), nil
+
+	// set(name, value) sets the named property to a string, number, or boolean.
+	case "set":
+		return starlark.NewBuiltin("set", func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			var propName string
+			var value starlark.Value
+			if err := starlark.UnpackPositionalArgs(fn.Name(), args, kwargs, 2, &propName, &value); err != nil {
+				return nil, err
+			}
+			data, err := starlarkToProperty(value)
+			if err != nil {
+				return nil, fmt.Errorf("set: %s", err.Error())
+			}
+			card.Properties.Get(propName).Set(data)
+			return starlark.None, nil
+		}).BindReceiver(sc), nil
+
+	case "has":
+		return starlark.NewBuiltin("has", func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			var propName string
+			if err := starlark.UnpackPositionalArgs(fn.Name(), args, kwargs, 1, &propName); err != nil {
+				return nil, err
+			}
+			return starlark.Bool(card.Properties.Has(propName)), nil
+		}).BindReceiver(sc), nil
+
+	case "set_type":
+		return starlark.NewBuiltin("set_type", func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			var contentType string
+			if err := starlark.UnpackPositionalArgs(fn.Name(), args, kwargs, 1, &contentType); err != nil {
+				return nil, err
+			}
+			for _, t := range ScriptableContentTypes {
+				if t == contentType {
+					card.SetContents(contentType)
+					return starlark.None, nil
+				}
+			}
+			return nil, fmt.Errorf("set_type: unknown card type %q", contentType)
+		}).BindReceiver(sc), nil
+
+	case "set_tags":
+		return starlark.NewBuiltin("set_tags", func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			tags := []string{}
+			for _, arg := range args {
+				tag, ok := starlark.AsString(arg)
+				if !ok {
+					return nil, fmt.Errorf("set_tags: tags must be strings, not %s", arg.Type())
+				}
+				tags = append(tags, tag)
+			}
+			card.SetTags(tags...)
+			return starlark.None, nil
+		}).BindReceiver(sc), nil
+
+	case "link", "unlink":
+		return starlark.NewBuiltin(name, func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			var other *scriptCard
+			if err := starlark.UnpackPositionalArgs(fn.Name(), args, kwargs, 1, &other); err != nil {
+				return nil, err
+			}
+			if fn.Name() == "link" {
+				card.Link(other.Card)
+			} else {
+				card.Unlink(other.Card)
+			}
+			return starlark.None, nil
+		}).BindReceiver(sc), nil
+
+	case "delete":
+		return starlark.NewBuiltin("delete", func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			if card.Valid {
+				card.Page.DeleteCards(card)
+			}
+			return starlark.None, nil
+		}).BindReceiver(sc), nil
+
+	}
+
+	return nil, nil
+
+}
+
+func (sc *scriptCard) SetField(name string, value starlark.Value) error {
+
+	v, ok := starlark.AsFloat(value)
+	if !ok {
+		return fmt.Errorf("card.%s must be a number, not %s", name, value.Type())
+	}
+
+	card := sc.Card
+
+	switch name {
+	case "x":
+		card.Move(float32(v)-card.Rect.X, 0)
+	case "y":
+		card.Move(0, float32(v)-card.Rect.Y)
+	case "w":
+		card.Recreate(float32(v), card.Rect.H)
+	case "h":
+		card.Recreate(card.Rect.W, float32(v))
+	default:
+		return starlark.NoSuchAttrError(fmt.Sprintf("card has no settable field .%s", name))
+	}
+
+	card.CreateUndoState = true
+
+	return nil
+
+}
+
+func propertyToStarlark(prop *Property) starlark.Value {
+
+	switch {
+	case prop.IsString():
+		return starlark.String(prop.AsString())
+	case prop.IsNumber():
+		return starlark.Float(prop.AsFloat())
+	case prop.IsBool():
+		return starlark.Bool(prop.AsBool())
+	}
+
+	return starlark.None
+
+}
+
+func starlarkToProperty(value starlark.Value) (interface{}, error) {
+
+	switch v := value.(type) {
+	case starlark.String:
+		return string(v), nil
+	case starlark.Bool:
+		return bool(v), nil
+	case starlark.Int, starlark.Float:
+		f, _ := starlark.AsFloat(v)
+		return f, nil
+	}
+
+	return nil, fmt.Errorf("properties can only be strings, numbers, or booleans, not %s", value.Type())
+
+}
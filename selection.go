@@ -60,6 +60,9 @@ func (selection *Selection) Update() {
 }
 
 func (selection *Selection) Add(card *Card) {
+	if card.Hidden {
+		return
+	}
 	if !card.selected {
 		card.Page.Raise(card)
 	}
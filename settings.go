@@ -10,31 +10,39 @@ import (
 )
 
 const (
-	SettingsPath                   = "MasterPlan/settings08.json"
-	SettingsLegacyPath             = "masterplan-settings08.json"
-	SettingsTheme                  = "Theme"
-	SettingsDownloadDirectory      = "DownloadDirectory"
-	SettingsWindowPosition         = "WindowPosition"
-	SettingsSaveWindowPosition     = "SaveWindowPosition"
-	SettingsCustomFontPath         = "CustomFontPath"
-	SettingsFontSize               = "FontSize"
-	SettingsTargetFPS              = "TargetFPS"
-	SettingsUnfocusedFPS           = "UnfocusedFPS"
-	SettingsDisableSplashscreen    = "DisableSplashscreen"
-	SettingsBorderlessWindow       = "BorderlessWindow"
-	SettingsAlwaysShowNumbering    = "AlwaysShowNumbering"
-	SettingsDisplayMessages        = "DisplayMessages"
-	SettingsDoubleClickMode        = "DoubleClickMode"
-	SettingsShowGrid               = "ShowGrid"
-	SettingsFlashSelected          = "FlashSelected"
-	SettingsFocusOnElapsedTimers   = "FocusOnElapsedTimers"
-	SettingsNotifyOnElapsedTimers  = "NotifyOnElapsedTimers"
-	SettingsPlayAlarmSound         = "PlayAlarmSound"
-	SettingsAudioVolume            = "AudioVolume"
-	SettingsShowAboutDialogOnStart = "ShowAboutDialogOnStart"
-	SettingsReversePan             = "ReversePan"
-	SettingsAutoLoadLastProject    = "AutoLoadLastProject"
-	SettingsScreenshotPath         = "ScreenshotPath"
+	SettingsPath                    = "MasterPlan/settings08.json"
+	SettingsLegacyPath              = "masterplan-settings08.json"
+	SettingsTheme                   = "Theme"
+	SettingsDownloadDirectory       = "DownloadDirectory"
+	SettingsWindowPosition          = "WindowPosition"
+	SettingsSaveWindowPosition      = "SaveWindowPosition"
+	SettingsCustomFontPath          = "CustomFontPath"
+	SettingsFontSize                = "FontSize"
+	SettingsTargetFPS               = "TargetFPS"
+	SettingsUnfocusedFPS            = "UnfocusedFPS"
+	SettingsDisableSplashscreen     = "DisableSplashscreen"
+	SettingsBorderlessWindow        = "BorderlessWindow"
+	SettingsAlwaysShowNumbering     = "AlwaysShowNumbering"
+	SettingsDisplayMessages         = "DisplayMessages"
+	SettingsDoubleClickMode         = "DoubleClickMode"
+	SettingsShowGrid                = "ShowGrid"
+	SettingsFlashSelected           = "FlashSelected"
+	SettingsFocusOnElapsedTimers    = "FocusOnElapsedTimers"
+	SettingsNotifyOnElapsedTimers   = "NotifyOnElapsedTimers"
+	SettingsPlayAlarmSound          = "PlayAlarmSound"
+	SettingsNotifyOnReminders       = "NotifyOnReminders"
+	SettingsRemindersPlayAlarmSound = "RemindersPlayAlarmSound"
+	SettingsAudioVolume             = "AudioVolume"
+	SettingsShowAboutDialogOnStart  = "ShowAboutDialogOnStart"
+	SettingsReversePan              = "ReversePan"
+	SettingsAutoLoadLastProject     = "AutoLoadLastProject"
+	SettingsScreenshotPath          = "ScreenshotPath"
+	SettingsAutomationServer        = "AutomationServer"
+	SettingsAutomationServerAddress = "AutomationServerAddress"
+	SettingsAutomationServerToken   = "AutomationServerToken"
+	SettingsPersistentUndoHistory   = "PersistentUndoHistory"
+	SettingsUndoHistoryFrameCap     = "UndoHistoryFrameCap"
+	SettingsUndoMemoryBudget        = "UndoMemoryBudget"
 
 	DoubleClickLast     = "Creates card of prev. type"
 	DoubleClickCheckbox = "Creates Checkbox card"
@@ -57,12 +65,31 @@ func NewProgramSettings() *Properties {
 	props.Get(SettingsFocusOnElapsedTimers).Set(false)
 	props.Get(SettingsNotifyOnElapsedTimers).Set(true)
 	props.Get(SettingsPlayAlarmSound).Set(true)
+	props.Get(SettingsNotifyOnReminders).Set(true)
+	props.Get(SettingsRemindersPlayAlarmSound).Set(false)
 	props.Get(SettingsAudioVolume).Set(80.0)
 	props.Get(SettingsShowAboutDialogOnStart).Set(true)
 	props.Get(SettingsReversePan).Set(false)
 	props.Get(SettingsCustomFontPath).Set("")
 	props.Get(SettingsScreenshotPath).Set("")
 	props.Get(SettingsAutoLoadLastProject).Set(false)
+	props.Get(SettingsAutomationServerAddress).Set(RPCDefaultAddress)
+	props.Get(SettingsAutomationServerToken).Set("")
+	props.Get(SettingsPersistentUndoHistory).Set(false)
+	props.Get(SettingsUndoHistoryFrameCap).Set(100.0)
+	props.Get(SettingsUndoMemoryBudget).Set(64.0)
+
+	automationServer := props.Get(SettingsAutomationServer)
+	automationServer.Set(false)
+	automationServer.OnChange = func() {
+		if globals.RPCServer != nil {
+			if automationServer.AsBool() {
+				globals.RPCServer.Start()
+			} else {
+				globals.RPCServer.Stop()
+			}
+		}
+	}
 
 	borderless := props.Get(SettingsBorderlessWindow)
 	borderless.Set(false)
@@ -95,6 +122,10 @@ func NewProgramSettings() *Properties {
 			}
 		}
 
+		for _, command := range gjson.Get(string(jsonData), "recent commands").Array() {
+			globals.RecentCommands = append(globals.RecentCommands, command.String())
+		}
+
 		globals.Keybindings.Deserialize(string(jsonData))
 
 	}
@@ -116,6 +147,8 @@ func SaveSettings() {
 
 	saveData, _ = sjson.Set(saveData, "recent files", globals.RecentFiles)
 
+	saveData, _ = sjson.Set(saveData, "recent commands", globals.RecentCommands)
+
 	saveData, _ = sjson.SetRaw(saveData, "keybindings", globals.Keybindings.Serialize())
 
 	saveData = gjson.Get(saveData, "@pretty").String()
@@ -0,0 +1,126 @@
+package main
+
+import "sort"
+
+// Which Pages the Stats menu covers.
+const (
+	StatsScopePage         = "This Page"
+	StatsScopeWithSubpages = "With Sub-Pages"
+	StatsScopeProject      = "Whole Project"
+)
+
+// SubPages returns the Pages that Sub-Page Cards on the Page lead to.
+func (page *Page) SubPages() []*Page {
+
+	subPages := []*Page{}
+
+	for _, card := range page.Cards {
+		if !card.Valid {
+			continue
+		}
+		if sb, ok := card.Contents.(*SubPageContents); ok && sb.SubPage != nil {
+			subPages = append(subPages, sb.SubPage)
+		}
+	}
+
+	return subPages
+
+}
+
+// DisplayName returns the Page's name for showing in menus.
+func (page *Page) DisplayName() string {
+	if page.UpwardPage == nil && page.Project.PageIndex(page) == 0 {
+		return "Root"
+	}
+	return page.Name
+}
+
+// withDescendants returns the Page, followed by every Page that can be reached from it through Sub-Page Cards.
+func (page *Page) withDescendants() []*Page {
+
+	pages := []*Page{}
+	visited := map[*Page]bool{}
+
+	var visit func(p *Page)
+
+	visit = func(p *Page) {
+		if visited[p] {
+			return
+		}
+		visited[p] = true
+		pages = append(pages, p)
+		for _, sub := range p.SubPages() {
+			visit(sub)
+		}
+	}
+
+	visit(page)
+
+	return pages
+
+}
+
+// StatsPages returns the Pages covered by the given stats scope.
+func (project *Project) StatsPages(scope string) []*Page {
+
+	switch scope {
+	case StatsScopeWithSubpages:
+		return project.CurrentPage.withDescendants()
+	case StatsScopeProject:
+		return project.Pages[0].withDescendants()
+	}
+
+	return []*Page{project.CurrentPage}
+
+}
+
+// PageStats is how far along the tasks (Cards that can be completed) on a Page are.
+type PageStats struct {
+	Page      *Page
+	Total     int
+	Completed int
+}
+
+func (stats PageStats) Percentage() int {
+	if stats.Total == 0 {
+		return 0
+	}
+	return stats.Completed * 100 / stats.Total
+}
+
+func NewPageStats(page *Page) PageStats {
+
+	stats := PageStats{Page: page}
+
+	for _, card := range page.Cards {
+		if card.Valid && card.Numberable() {
+			stats.Total++
+			if card.Completed() {
+				stats.Completed++
+			}
+		}
+	}
+
+	return stats
+
+}
+
+// ContentTypeCounts returns how many of the given Cards there are of each content type, along with the content types in
+// alphabetical order.
+func ContentTypeCounts(cards []*Card) (map[string]int, []string) {
+
+	counts := map[string]int{}
+	types := []string{}
+
+	for _, card := range cards {
+		if _, exists := counts[card.ContentType]; !exists {
+			types = append(types, card.ContentType)
+		}
+		counts[card.ContentType]++
+	}
+
+	sort.Strings(types)
+
+	return counts, types
+
+}
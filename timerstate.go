@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gen2brain/beeep"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// A Timer's state is kept in its Card's Properties so it's saved with the project. While it's running, "timer started" is when
+// it was last started (in Unix seconds) and "timer accumulated" is how much time it had on it then; while it's stopped, "timer
+// started" is 0 and "timer accumulated" is the time on it.
+const (
+	timerStartedProperty     = "timer started"
+	timerAccumulatedProperty = "timer accumulated"
+)
+
+// pomodoroCatchUpLimit is the most Pomodoro phases that are caught up on after the project's been closed, so a Pomodoro that
+// was left running for a long time doesn't go through thousands of them.
+const pomodoroCatchUpLimit = 100
+
+// parseClockTime parses a Timer's "mm:ss" max time.
+func parseClockTime(text string) time.Duration {
+
+	timeUnits := strings.Split(text, ":")
+	if len(timeUnits) < 2 {
+		return 0
+	}
+
+	minutes, _ := strconv.Atoi(timeUnits[0])
+	seconds, _ := strconv.Atoi(timeUnits[1])
+
+	return time.Duration((minutes * int(time.Minute)) + (seconds * int(time.Second)))
+
+}
+
+// restoreState restores the Timer's state from its Card's Properties. When the project's being loaded, a Timer that was
+// running when it was saved picks up where it left off, with the time that's passed since added on.
+func (tc *TimerContents) restoreState() {
+
+	props := tc.Card.Properties
+	project := tc.Card.Page.Project
+
+	if props.Has("max time") && props.Get("max time").IsString() {
+		tc.MaxTime = parseClockTime(props.Get("max time").AsString())
+	}
+
+	if props.Has("pomodoro phase") {
+		switch phase := props.Get("pomodoro phase").AsString(); phase {
+		case PomodoroPhaseWork, PomodoroPhaseShortBreak, PomodoroPhaseLongBreak:
+			tc.PomodoroPhase = phase
+		}
+		tc.PomodoroCycle = int(props.Get("pomodoro cycle").AsFloat())
+	}
+
+	if props.Has(timerAccumulatedProperty) && props.Get(timerAccumulatedProperty).IsNumber() {
+		tc.TimerValue = time.Duration(props.Get(timerAccumulatedProperty).AsFloat() * float64(time.Second))
+	}
+
+	started := float64(0)
+	if props.Has(timerStartedProperty) && props.Get(timerStartedProperty).IsNumber() {
+		started = props.Get(timerStartedProperty).AsFloat()
+	}
+
+	tc.persistedRunning = false
+	tc.persistedValue = tc.TimerValue
+	tc.persistedPhase = tc.PomodoroPhase
+
+	if started <= 0 {
+		return
+	}
+
+	// Only Timers in a project that's being opened resume; others (i.e. pasted ones) have to be started again.
+	if !project.Loading || project.ReadOnly {
+		tc.persistPending = true
+		return
+	}
+
+	tc.resumedFrom = time.Unix(0, int64(started*float64(time.Second)))
+	tc.resumedWith = tc.TimerValue
+	tc.TimerValue += time.Since(tc.resumedFrom)
+	tc.Running = true
+	tc.persistedRunning = true
+
+	if int(props.Get("mode group").AsFloat()) == TimerModeStopwatch {
+		// The time tracking session carries on from when the Timer was actually started.
+		tc.wasRunning = true
+		tc.SessionStart = tc.resumedFrom
+	}
+
+}
+
+// catchUp deals with anything that would have happened to a resumed Timer while MasterPlan was closed. It's called once the
+// project's done loading, so the Timer's links are in place to be triggered.
+func (tc *TimerContents) catchUp() {
+
+	if tc.resumedFrom.IsZero() {
+		return
+	}
+
+	resumedFrom := tc.resumedFrom
+	tc.resumedFrom = time.Time{}
+
+	switch int(tc.Card.Properties.Get("mode group").AsFloat()) {
+
+	case TimerModeCountdown:
+
+		if tc.MaxTime > 0 && tc.TimerValue > tc.MaxTime {
+
+			expiredAt := resumedFrom.Add(tc.MaxTime - tc.resumedWith)
+
+			tc.Running = false
+			tc.TimerValue = 0
+			tc.Pie.FillPercent = 0
+
+			tc.triggerLinks()
+			tc.catchUpAlarm(fmt.Sprintf("Timer [%s] elapsed at %s, while MasterPlan was closed.", tc.Name.TextAsString(), expiredAt.Format(TimestampDisplayFormat)))
+
+		}
+
+	case TimerModePomodoro:
+
+		phases := 0
+		for tc.TimerValue >= tc.pomodoroLength(tc.PomodoroPhase) && phases < pomodoroCatchUpLimit {
+			tc.TimerValue -= tc.pomodoroLength(tc.PomodoroPhase)
+			tc.advancePomodoro()
+			phases++
+		}
+
+		if phases > 0 {
+			tc.persistPending = true
+			tc.catchUpAlarm(fmt.Sprintf("Pomodoro [%s] went through %d phase(s) while MasterPlan was closed; it's now on %s.", tc.Name.TextAsString(), phases, tc.pomodoroStatusText()))
+		}
+
+	}
+
+}
+
+// persistState records the Timer's state in its Card's Properties whenever it's started, stopped, or reset.
+func (tc *TimerContents) persistState() {
+
+	project := tc.Card.Page.Project
+
+	if project.Loading || project.ReadOnly {
+		return
+	}
+
+	// The time on the Timer only goes down when it's reset.
+	if !tc.persistPending && tc.Running == tc.persistedRunning && tc.TimerValue >= tc.persistedValue && tc.PomodoroPhase == tc.persistedPhase {
+		tc.persistedValue = tc.TimerValue
+		return
+	}
+
+	tc.persistPending = false
+	tc.persistedRunning = tc.Running
+	tc.persistedValue = tc.TimerValue
+	tc.persistedPhase = tc.PomodoroPhase
+
+	props := tc.Card.Properties
+
+	if tc.Running {
+		props.Get(timerStartedProperty).SetRaw(float64(time.Now().UnixNano()) / float64(time.Second))
+	} else {
+		props.Get(timerStartedProperty).SetRaw(0.0)
+	}
+
+	props.Get(timerAccumulatedProperty).SetRaw(tc.TimerValue.Seconds())
+	props.Get("pomodoro phase").SetRaw(tc.PomodoroPhase)
+	props.Get("pomodoro cycle").SetRaw(float64(tc.PomodoroCycle))
+
+	project.Modified = true
+
+}
+
+// catchUpAlarm alerts the user of something that happened to the Timer while MasterPlan was closed. As MasterPlan's usually
+// focused just after opening a project, this notifies the user even if it's focused.
+func (tc *TimerContents) catchUpAlarm(message string) {
+
+	globals.EventLog.Log(message)
+
+	tc.alarm(message)
+
+	if globals.Settings.Get(SettingsNotifyOnElapsedTimers).AsBool() && globals.WindowFlags&sdl.WINDOW_INPUT_FOCUS > 0 {
+		beeep.Notify("MasterPlan", message, "")
+	}
+
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The names of a Card's timestamp Properties, each holding an RFC 3339 time. A Card's completed time is only set while it's
+// completed.
+const (
+	TimestampCreated   = "created"
+	TimestampModified  = "modified"
+	TimestampCompleted = "completed"
+)
+
+const TimestampDisplayFormat = "Mon Jan 2 2006 15:04"
+
+// Timestamp returns the time stored in the named timestamp property of the Card, or the zero time if it isn't set.
+func (card *Card) Timestamp(name string) time.Time {
+
+	if !card.Properties.Has(name) {
+		return time.Time{}
+	}
+
+	prop := card.Properties.Props[name]
+	if !prop.IsString() {
+		return time.Time{}
+	}
+
+	t, err := time.Parse(time.RFC3339, prop.AsString())
+	if err != nil {
+		return time.Time{}
+	}
+
+	// A Card that's been unchecked since by undoing keeps its completed time around, but it no longer counts.
+	if name == TimestampCompleted && !card.Completed() {
+		return time.Time{}
+	}
+
+	return t
+
+}
+
+// setTimestamp sets the named timestamp of the Card. This doesn't count as a change to the Card by itself, so it doesn't create
+// an undo state.
+func (card *Card) setTimestamp(name string, t time.Time) {
+	card.Properties.Get(name).SetRaw(t.Format(time.RFC3339))
+}
+
+// TimestampSummary returns a line for each timestamp that's set on the Card, for displaying.
+func (card *Card) TimestampSummary() []string {
+
+	lines := []string{}
+
+	for _, name := range []string{TimestampCreated, TimestampModified, TimestampCompleted} {
+		if t := card.Timestamp(name); !t.IsZero() {
+			lines = append(lines, strings.Title(name)+": "+t.Format(TimestampDisplayFormat))
+		}
+	}
+
+	return lines
+
+}
+
+// updateCompletedTime records when the Card is completed, and clears it when the Card's no longer completed.
+func (card *Card) updateCompletedTime() {
+
+	// Deserializing a Card (loading it, pasting it, undoing to it, etc) brings its completed time along with it.
+	keep := card.keepCompletedTime || card.Page.Project.Loading
+	card.keepCompletedTime = false
+
+	completed := card.Completed()
+
+	if completed == card.wasCompleted {
+		return
+	}
+
+	card.wasCompleted = completed
+
+	if keep {
+		return
+	}
+
+	if completed {
+		card.setTimestamp(TimestampCompleted, time.Now())
+	} else {
+		card.Properties.Remove(TimestampCompleted)
+	}
+
+}
+
+// captureChange captures the Card's state in the undo history, updating its modified time first if it's actually changed
+// since its last undo state.
+func (card *Card) captureChange() {
+
+	history := card.Page.Project.UndoHistory
+	state := NewUndoState(card)
+
+	if history.On && !card.Page.Project.Loading {
+		if prev := history.previousState(card); prev == nil || !state.SameAs(prev) {
+			card.setTimestamp(TimestampModified, time.Now())
+			state = NewUndoState(card)
+		}
+	}
+
+	history.Capture(state)
+
+}
+
+// ParseTimestampQuery parses searches for Cards by when they were created, modified, or completed, like "completed this week"
+// or "created in the last 3 days". It returns the timestamp to check and the range of time it has to fall in.
+func ParseTimestampQuery(query string, now time.Time) (timestamp string, start, end time.Time, ok bool) {
+
+	words := strings.Fields(strings.ToLower(query))
+
+	if len(words) < 2 {
+		return "", start, end, false
+	}
+
+	switch words[0] {
+	case TimestampCreated, TimestampModified, TimestampCompleted:
+		timestamp = words[0]
+	default:
+		return "", start, end, false
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	// Weeks start on Monday.
+	weekStart := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+
+	switch rest := strings.Join(words[1:], " "); rest {
+	case "today":
+		start, end = today, today.AddDate(0, 0, 1)
+	case "yesterday":
+		start, end = today.AddDate(0, 0, -1), today
+	case "this week":
+		start, end = weekStart, weekStart.AddDate(0, 0, 7)
+	case "last week":
+		start, end = weekStart.AddDate(0, 0, -7), weekStart
+	case "this month":
+		start, end = monthStart, monthStart.AddDate(0, 1, 0)
+	case "last month":
+		start, end = monthStart.AddDate(0, -1, 0), monthStart
+	case "this year":
+		start = time.Date(now.Year(), 1, 1, 0, 0, 0, 0, now.Location())
+		end = start.AddDate(1, 0, 0)
+	default:
+
+		// "in the last N days" or "last N days"
+		rest = strings.TrimPrefix(rest, "in the ")
+		fields := strings.Fields(rest)
+
+		if len(fields) != 3 || fields[0] != "last" || (fields[2] != "days" && fields[2] != "day") {
+			return "", start, end, false
+		}
+
+		days, err := strconv.Atoi(fields[1])
+		if err != nil || days < 1 {
+			return "", start, end, false
+		}
+
+		start, end = today.AddDate(0, 0, 1-days), today.AddDate(0, 0, 1)
+
+	}
+
+	return timestamp, start, end, true
+
+}
+
+// MatchesTimestampQuery returns if the Card's timestamp falls within the range given by ParseTimestampQuery().
+func (card *Card) MatchesTimestampQuery(timestamp string, start, end time.Time) bool {
+	t := card.Timestamp(timestamp)
+	return !t.IsZero() && !t.Before(start) && t.Before(end)
+}
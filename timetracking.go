@@ -0,0 +1,278 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// TimeSessionsProperty holds the sessions logged on a Checkbox Card, as a JSON array of objects with "start" and "end" Unix times.
+const TimeSessionsProperty = "time sessions"
+
+const (
+	TimeReportByPage = "By Page"
+	TimeReportByTag  = "By Tag"
+)
+
+// TimeReportNoTag is what sessions on Cards without any tags are reported under.
+const TimeReportNoTag = "(No Tag)"
+
+// A TimeSession is a stretch of time spent on a task, logged by a stopwatch Timer linked or stacked with it.
+type TimeSession struct {
+	Start time.Time
+	End   time.Time
+}
+
+func (session TimeSession) Duration() time.Duration {
+	return session.End.Sub(session.Start)
+}
+
+// TimeSessions returns the sessions logged on the Card, oldest first.
+func (card *Card) TimeSessions() []TimeSession {
+
+	sessions := []TimeSession{}
+
+	if !card.Properties.Has(TimeSessionsProperty) {
+		return sessions
+	}
+
+	prop := card.Properties.Props[TimeSessionsProperty]
+	if !prop.IsString() {
+		return sessions
+	}
+
+	for _, session := range gjson.Parse(prop.AsString()).Array() {
+		sessions = append(sessions, TimeSession{
+			Start: time.Unix(session.Get("start").Int(), 0),
+			End:   time.Unix(session.Get("end").Int(), 0),
+		})
+	}
+
+	return sessions
+
+}
+
+// LogTimeSession adds a session to the Card.
+func (card *Card) LogTimeSession(session TimeSession) {
+
+	data := "[]"
+	if prop := card.Properties.Get(TimeSessionsProperty); prop.IsString() {
+		data = prop.AsString()
+	}
+
+	sessionData, _ := sjson.Set("{}", "start", session.Start.Unix())
+	sessionData, _ = sjson.Set(sessionData, "end", session.End.Unix())
+	data, _ = sjson.SetRaw(data, "-1", sessionData)
+
+	card.Properties.Get(TimeSessionsProperty).Set(data)
+
+}
+
+// TimeSpent returns the total time logged on the Card. It's cached, as it's drawn every frame.
+func (card *Card) TimeSpent() time.Duration {
+
+	if !card.timeSpentCached {
+		card.timeSpent = 0
+		for _, session := range card.TimeSessions() {
+			card.timeSpent += session.Duration()
+		}
+		card.timeSpentCached = true
+	}
+
+	return card.timeSpent
+
+}
+
+// formatTimeSpent formats a duration in hours and minutes, as formatTime() only goes up to minutes.
+func formatTimeSpent(d time.Duration) string {
+	minutes := int(d.Minutes())
+	if minutes < 60 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh %02dm", minutes/60, minutes%60)
+}
+
+// TrackedCards returns the Checkbox Cards the Timer logs sessions onto: those it's linked to, or stacked with.
+func (tc *TimerContents) TrackedCards() []*Card {
+
+	cards := []*Card{}
+	added := map[*Card]bool{}
+
+	add := func(card *Card) {
+		if card != nil && card != tc.Card && card.Valid && card.ContentType == ContentTypeCheckbox && !added[card] {
+			cards = append(cards, card)
+			added[card] = true
+		}
+	}
+
+	for _, link := range tc.Card.Links {
+		add(link.Start)
+		add(link.End)
+	}
+
+	for _, card := range tc.Card.Stack.All() {
+		add(card)
+	}
+
+	return cards
+
+}
+
+// trackSession starts a session when a stopwatch Timer starts running, and logs it onto the Timer's tracked Cards when it
+// stops.
+func (tc *TimerContents) trackSession() {
+
+	if tc.Running == tc.wasRunning {
+		return
+	}
+
+	tc.wasRunning = tc.Running
+
+	if tc.Running {
+		if int(tc.Card.Properties.Get("mode group").AsFloat()) == TimerModeStopwatch {
+			tc.SessionStart = time.Now()
+		}
+		return
+	}
+
+	if tc.SessionStart.IsZero() {
+		return
+	}
+
+	session := TimeSession{Start: tc.SessionStart, End: time.Now()}
+	tc.SessionStart = time.Time{}
+
+	// Too short to be worth keeping.
+	if session.Duration() < time.Second {
+		return
+	}
+
+	cards := tc.TrackedCards()
+
+	for _, card := range cards {
+		card.LogTimeSession(session)
+	}
+
+	if len(cards) > 0 {
+		globals.EventLog.Log("Logged %s on %d task(s) from timer [%s].", formatTime(session.Duration(), false), len(cards), tc.Name.TextAsString())
+	}
+
+}
+
+// TimeReportRow is the time logged under a Page or tag.
+type TimeReportRow struct {
+	Name     string
+	Sessions int
+	Duration time.Duration
+}
+
+// trackedCards returns all Cards in the Project that have time logged on them.
+func (project *Project) trackedCards() []*Card {
+
+	cards := []*Card{}
+
+	for _, page := range project.Pages {
+		if page.ReferenceCount <= 0 {
+			continue
+		}
+		for _, card := range page.Cards {
+			if card.Valid && card.Properties.Has(TimeSessionsProperty) {
+				cards = append(cards, card)
+			}
+		}
+	}
+
+	return cards
+
+}
+
+// TimeReport totals the time logged across the Project, grouped by Page or by tag. Cards with multiple tags count towards each
+// of them.
+func (project *Project) TimeReport(grouping string) []TimeReportRow {
+
+	rows := map[string]*TimeReportRow{}
+	names := []string{}
+
+	add := func(name string, sessions []TimeSession) {
+		row, exists := rows[name]
+		if !exists {
+			row = &TimeReportRow{Name: name}
+			rows[name] = row
+			names = append(names, name)
+		}
+		for _, session := range sessions {
+			row.Sessions++
+			row.Duration += session.Duration()
+		}
+	}
+
+	for _, card := range project.trackedCards() {
+
+		sessions := card.TimeSessions()
+		if len(sessions) == 0 {
+			continue
+		}
+
+		if grouping == TimeReportByTag {
+			tags := card.Tags()
+			if len(tags) == 0 {
+				tags = []string{TimeReportNoTag}
+			}
+			for _, tag := range tags {
+				add(tag, sessions)
+			}
+		} else {
+			add(card.Page.DisplayName(), sessions)
+		}
+
+	}
+
+	report := []TimeReportRow{}
+	for _, name := range names {
+		report = append(report, *rows[name])
+	}
+
+	sort.SliceStable(report, func(i, j int) bool { return report[i].Duration > report[j].Duration })
+
+	return report
+
+}
+
+// ExportTimeSessionsCSV writes every session logged in the Project to a CSV file, one row per session.
+func (project *Project) ExportTimeSessionsCSV(filename string) error {
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+
+	writer.Write([]string{"page", "task", "tags", "start", "end", "hours"})
+
+	for _, card := range project.trackedCards() {
+		for _, session := range card.TimeSessions() {
+			writer.Write([]string{
+				card.Page.DisplayName(),
+				card.Properties.Get("description").AsString(),
+				strings.Join(card.Tags(), ", "),
+				session.Start.Format(time.RFC3339),
+				session.End.Format(time.RFC3339),
+				fmt.Sprintf("%.2f", session.Duration().Hours()),
+			})
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+
+}
@@ -1,7 +1,13 @@
 package main
 
 import (
+	"crypto/sha256"
 	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
 )
 
 // HISTORY    v
@@ -15,15 +21,23 @@ import (
 // the "frame" forward or back a step, and it sets all Cards to the next availale key, looking forwards or backwards
 // from the current frame, in their respective lanes.
 
-// Note that this could be easily transformed to work with any undoable objects, not just Cards.
+// Frames form a tree: capturing a change after undoing starts a new branch, rather than throwing away the frames that were
+// undone. Frames is the branch currently being worked on, from the root to its tip.
+
+// Lanes aren't limited to Cards; anything that implements Undoable can have one (Pages and LinkEndings do, as well).
 type UndoHistory struct {
 	Project      *Project
 	Frames       []*UndoFrame
+	Roots        []*UndoFrame
+	Base         *UndoFrame // The states of objects from before they were first tracked; they can be undone to, but not undone
 	CurrentFrame *UndoFrame
 	On           bool
 	Index        int
 	Changed      bool
 	MinimumFrame int
+	Revision     int // Incremented whenever the history or the current frame changes
+
+	memoryUsage int // Roughly how many bytes the UndoStates in the history take up, kept up to date as they're added and removed
 }
 
 func NewUndoHistory(project *Project) *UndoHistory {
@@ -32,6 +46,7 @@ func NewUndoHistory(project *Project) *UndoHistory {
 		Project:      project,
 		On:           true,
 		Frames:       []*UndoFrame{},
+		Base:         NewUndoFrame(),
 		CurrentFrame: NewUndoFrame(),
 	}
 
@@ -44,27 +59,75 @@ func NewUndoHistory(project *Project) *UndoHistory {
 // we need both an old state (where it was previously), and a new State (where it's been moved).
 func (history *UndoHistory) Capture(undoState *UndoState) {
 
-	if !history.On {
+	if history.addState(undoState) {
+		history.Changed = true
+	}
+
+}
+
+// CaptureAlong adds the UndoState to the current frame like Capture(), but it doesn't count as a change by itself; it's only
+// added to the history along with the next change that does. This is for changes that shouldn't be undo steps of their own,
+// like moving a Page's view.
+func (history *UndoHistory) CaptureAlong(undoState *UndoState) {
+
+	if history.addState(undoState) {
 		return
 	}
 
-	if len(history.Frames) > 0 {
+	// The object's back the way it was, so there's nothing to add along with the next change anymore.
+	if existing, exists := history.CurrentFrame.States[undoState.Object]; exists && history.On {
+		history.memoryUsage -= existing.size()
+		delete(history.CurrentFrame.States, undoState.Object)
+	}
 
-		for i := history.Index - 1; i >= 0; i-- {
-			if prevState, exists := history.Frames[i].States[undoState.Card]; exists {
-				if undoState.SameAs(prevState) {
-					return
-				} else {
-					break
-				}
-			}
+}
+
+// addState adds the UndoState to the current frame, returning false if it wasn't added as it's the same as the object's
+// previous state.
+func (history *UndoHistory) addState(undoState *UndoState) bool {
+
+	if !history.On {
+		return false
+	}
+
+	if prevState := history.previousState(undoState.Object); prevState != nil {
+
+		if undoState.SameAs(prevState) {
+			return false
 		}
 
+		undoState.compress(prevState)
+
 	}
 
-	history.CurrentFrame.States[undoState.Card] = undoState
+	if existing, exists := history.CurrentFrame.States[undoState.Object]; exists {
+		history.memoryUsage -= existing.size()
+	}
 
-	history.Changed = true
+	history.CurrentFrame.States[undoState.Object] = undoState
+	history.memoryUsage += undoState.size()
+
+	return true
+
+}
+
+// Track makes sure an object has a lane in the history before it's changed. Objects other than Cards don't get a lane when
+// they're created, so without one, the first change made to them would have no earlier state to be undone to. The object's
+// current state goes in the Base frame rather than any frame in the history, so undoing a frame can't uncreate the object
+// unless the frame actually created it.
+func (history *UndoHistory) Track(object Undoable) {
+
+	if !history.On {
+		return
+	}
+
+	if _, exists := history.CurrentFrame.States[object]; exists || history.previousState(object) != nil {
+		return
+	}
+
+	state := NewUndoState(object)
+	history.Base.States[object] = state
+	history.memoryUsage += state.size()
 
 }
 
@@ -76,14 +139,16 @@ func (history *UndoHistory) Undo() bool {
 
 		globals.EventLog.On = false
 
-		affected := []*Card{}
+		affected := []Undoable{}
+
+		frame := history.Frames[history.Index-1]
 
-		for _, state := range history.Frames[history.Index-1].States {
-			affected = append(affected, state.Card)
+		for _, state := range frame.States {
+			affected = append(affected, state.Object)
 		}
 
-		if affected[0].Page != history.Project.CurrentPage {
-			history.Project.SetPage(affected[0].Page)
+		if page := frame.UndoPage(); page != history.Project.CurrentPage {
+			history.Project.SetPage(page)
 			history.On = true
 			return false
 		}
@@ -92,20 +157,10 @@ func (history *UndoHistory) Undo() bool {
 
 		for _, affected := range affected {
 
-			foundState := false
-
-			for i := history.Index; i > 0; i-- {
-
-				if state, exists := history.Frames[i-1].States[affected]; exists {
-					state.Apply()
-					foundState = true
-					break
-				}
-
-			}
-
-			if !foundState {
-				affected.Page.DeleteCards(affected)
+			if state := history.previousState(affected); state != nil {
+				state.Apply()
+			} else {
+				affected.UndoUncreate()
 			}
 
 		}
@@ -122,6 +177,8 @@ func (history *UndoHistory) Undo() bool {
 
 		history.Project.Modified = true
 
+		history.Revision++
+
 		return true
 
 	}
@@ -139,14 +196,16 @@ func (history *UndoHistory) Redo() bool {
 
 		globals.EventLog.On = false
 
-		affected := []*Card{}
+		affected := []Undoable{}
+
+		frame := history.Frames[history.Index]
 
-		for _, state := range history.Frames[history.Index].States {
-			affected = append(affected, state.Card)
+		for _, state := range frame.States {
+			affected = append(affected, state.Object)
 		}
 
-		if affected[0].Page != history.Project.CurrentPage {
-			history.Project.SetPage(affected[0].Page)
+		if page := frame.UndoPage(); page != history.Project.CurrentPage {
+			history.Project.SetPage(page)
 			history.On = true
 			return false
 		}
@@ -158,7 +217,7 @@ func (history *UndoHistory) Redo() bool {
 			if state, exists := history.Frames[history.Index-1].States[affected]; exists {
 				state.Apply()
 			} else {
-				affected.Page.DeleteCards(affected)
+				affected.UndoUncreate()
 			}
 
 		}
@@ -175,6 +234,8 @@ func (history *UndoHistory) Redo() bool {
 
 		history.Project.Modified = true
 
+		history.Revision++
+
 		return true
 
 	}
@@ -189,11 +250,23 @@ func (history *UndoHistory) Update() {
 
 	if history.Changed {
 
+		frame := history.CurrentFrame
+		frame.Label = history.frameLabel(frame)
+		frame.Time = time.Now()
+
+		// Any frames past the current one stay reachable through their parent, as an abandoned branch.
+		if history.Index > 0 {
+			frame.Parent = history.Frames[history.Index-1]
+			frame.Parent.Children = append(frame.Parent.Children, frame)
+		} else {
+			history.Roots = append(history.Roots, frame)
+		}
+
 		if len(history.Frames) > 0 {
 			history.Frames = history.Frames[:history.Index]
 		}
 
-		history.Frames = append(history.Frames, history.CurrentFrame)
+		history.Frames = append(history.Frames, frame)
 
 		history.CurrentFrame = NewUndoFrame()
 
@@ -205,8 +278,360 @@ func (history *UndoHistory) Update() {
 
 		history.Changed = false
 
+		history.Revision++
+
+		history.Prune()
+
+	}
+
+}
+
+// MemoryUsage returns roughly how many bytes the UndoStates in the history take up. A state that's shared between branches
+// (after their common root's been pruned) is counted once for each.
+func (history *UndoHistory) MemoryUsage() int {
+	return history.memoryUsage
+}
+
+// countMemoryUsage totals up the size of every UndoState in the history from scratch; it's only needed when the history's
+// replaced wholesale.
+func (history *UndoHistory) countMemoryUsage() {
+
+	history.memoryUsage = 0
+
+	for _, entry := range history.Tree() {
+		for _, state := range entry.Frame.States {
+			history.memoryUsage += state.size()
+		}
+	}
+
+	for _, frame := range []*UndoFrame{history.Base, history.CurrentFrame} {
+		for _, state := range frame.States {
+			history.memoryUsage += state.size()
+		}
+	}
+
+}
+
+// Prune removes the oldest frames from the history until it fits within the undo memory budget set in the program settings.
+// Frames on abandoned branches are pruned along with those on the current one; the frame the Project's currently at is
+// always kept.
+func (history *UndoHistory) Prune() {
+
+	budget := int(globals.Settings.Get(SettingsUndoMemoryBudget).AsFloat() * 1024 * 1024)
+
+	if budget <= 0 {
+		return
+	}
+
+	pruned := 0
+
+	for history.memoryUsage > budget {
+
+		// Frames are always newer than their parents, so the oldest frame in the tree is one of its roots.
+		var oldest *UndoFrame
+
+		for _, root := range history.Roots {
+
+			// The first frame of the current branch can only go if there's a later frame on it to undo to.
+			if len(history.Frames) > 0 && root == history.Frames[0] && history.Index < 2 {
+				continue
+			}
+
+			if oldest == nil || root.Time.Before(oldest.Time) {
+				oldest = root
+			}
+
+		}
+
+		if oldest == nil {
+			break
+		}
+
+		history.pruneRoot(oldest)
+
+		pruned++
+
+	}
+
+	if pruned > 0 {
+		globals.EventLog.Log("Pruned %d undo steps to stay within the undo memory budget.", pruned)
+	}
+
+}
+
+// pruneRoot removes a root frame from the history. Its states are folded into the frames that follow it, so each of them
+// becomes the furthest point that can be undone to on its branch.
+func (history *UndoHistory) pruneRoot(root *UndoFrame) {
+
+	roots := []*UndoFrame{}
+	for _, r := range history.Roots {
+		if r != root {
+			roots = append(roots, r)
+		}
 	}
 
+	for _, child := range root.Children {
+
+		for object, state := range root.States {
+			if _, exists := child.States[object]; !exists {
+				child.States[object] = state
+				history.memoryUsage += state.size()
+			}
+		}
+
+		// Root frames hold full states, so nothing refers back to the frames that were removed.
+		for _, state := range child.States {
+			size := state.size()
+			state.materialize()
+			history.memoryUsage += state.size() - size
+		}
+
+		child.Parent = nil
+		roots = append(roots, child)
+
+	}
+
+	for _, state := range root.States {
+		history.memoryUsage -= state.size()
+	}
+
+	history.Roots = roots
+
+	if len(history.Frames) > 0 && history.Frames[0] == root {
+
+		history.Frames = history.Frames[1:]
+		history.Index--
+
+		if history.MinimumFrame > 1 {
+			history.MinimumFrame--
+		} else {
+			history.MinimumFrame = 1
+		}
+
+	}
+
+}
+
+// previousState returns the latest UndoState for the object before the current frame (falling back to the state it was in
+// when it was first tracked), or nil if there isn't one.
+func (history *UndoHistory) previousState(object Undoable) *UndoState {
+	for i := history.Index - 1; i >= 0; i-- {
+		if state, exists := history.Frames[i].States[object]; exists {
+			return state
+		}
+	}
+	return history.Base.States[object]
+}
+
+// frameLabel returns a readable description of the changes in an UndoFrame that's about to be added to the history.
+func (history *UndoHistory) frameLabel(frame *UndoFrame) string {
+
+	created, deleted, moved, checked, unchecked := 0, 0, 0, 0, 0
+	count := 0
+	var subject *Card
+
+	for object, state := range frame.States {
+
+		card, isCard := object.(*Card)
+
+		if !isCard {
+
+			// Changing a link's joints also captures the Card the link starts from.
+			if _, isLink := object.(*LinkEnding); isLink {
+				return "Edited link joints"
+			}
+
+			if page, isPage := object.(*Page); isPage && len(frame.States) == 1 {
+				if prev := history.previousState(page); prev != nil && gjson.Get(prev.Serialized(), "name").String() != page.Name {
+					return fmt.Sprintf("Renamed page to '%s'", page.Name)
+				}
+			}
+
+			continue
+
+		}
+
+		count++
+		subject = card
+		prev := history.previousState(card)
+
+		switch {
+		case state.Deletion:
+			deleted++
+		case prev == nil || prev.Deletion:
+			created++
+		case gjson.Get(prev.Serialized(), "properties.checked").Bool() != gjson.Get(state.Serialized(), "properties.checked").Bool():
+			if gjson.Get(state.Serialized(), "properties.checked").Bool() {
+				checked++
+			} else {
+				unchecked++
+			}
+		case gjson.Get(prev.Serialized(), "rect.X").Float() != gjson.Get(state.Serialized(), "rect.X").Float() || gjson.Get(prev.Serialized(), "rect.Y").Float() != gjson.Get(state.Serialized(), "rect.Y").Float():
+			moved++
+		}
+
+	}
+
+	cards := func(verb string) string {
+		if count == 1 {
+			return fmt.Sprintf("%s %s", verb, undoCardName(subject))
+		}
+		return fmt.Sprintf("%s %d Cards", verb, count)
+	}
+
+	switch {
+	case count == 0:
+		return "Changed page"
+	case count == created:
+		return cards("Created")
+	case count == deleted:
+		return cards("Deleted")
+	case count == checked:
+		return cards("Checked")
+	case count == unchecked:
+		return cards("Unchecked")
+	case count == moved:
+		return cards("Moved")
+	}
+
+	if globals.EventLog.LastMessageFrame == globals.Frame && globals.EventLog.LastMessage != "" {
+		return strings.TrimSuffix(globals.EventLog.LastMessage, ".")
+	}
+
+	return cards("Changed")
+
+}
+
+// undoCardName returns a short name for a Card for use in UndoFrame labels.
+func undoCardName(card *Card) string {
+
+	name := ""
+	if card.Properties.Has("description") {
+		name = strings.TrimSpace(strings.Split(card.Properties.Get("description").AsString(), "\n")[0])
+	}
+
+	if len([]rune(name)) > 24 {
+		name = string([]rune(name)[:24]) + "..."
+	}
+
+	if name == "" {
+		return card.ContentType
+	}
+
+	return fmt.Sprintf("%s '%s'", card.ContentType, name)
+
+}
+
+// UndoTreeEntry is a frame in the UndoHistory's tree, along with how deep into branches it is.
+type UndoTreeEntry struct {
+	Frame *UndoFrame
+	Depth int
+}
+
+// Tree returns every frame in the UndoHistory, in order. Each branch follows the frame it split off from, one level deeper;
+// the most recent branch from a frame continues at the same depth.
+func (history *UndoHistory) Tree() []UndoTreeEntry {
+
+	entries := []UndoTreeEntry{}
+
+	var walk func(frame *UndoFrame, depth int)
+
+	walk = func(frame *UndoFrame, depth int) {
+		for frame != nil {
+			entries = append(entries, UndoTreeEntry{Frame: frame, Depth: depth})
+			if len(frame.Children) == 0 {
+				return
+			}
+			for _, branch := range frame.Children[:len(frame.Children)-1] {
+				walk(branch, depth+1)
+			}
+			frame = frame.Children[len(frame.Children)-1]
+		}
+	}
+
+	for _, root := range history.Roots {
+		walk(root, 0)
+	}
+
+	return entries
+
+}
+
+// CurrentState returns the frame the Project is currently at, or nil if it's before the first frame.
+func (history *UndoHistory) CurrentState() *UndoFrame {
+	if history.Index > 0 && history.Index <= len(history.Frames) {
+		return history.Frames[history.Index-1]
+	}
+	return nil
+}
+
+// JumpTo returns the Project to the state it was in at the given frame, which can be on any branch. Redoing from there follows
+// the most recent branch onwards from that frame.
+func (history *UndoHistory) JumpTo(target *UndoFrame) {
+
+	path := []*UndoFrame{}
+	for f := target; f != nil; f = f.Parent {
+		path = append([]*UndoFrame{f}, path...)
+	}
+
+	targetIndex := len(path)
+
+	if targetIndex < history.MinimumFrame {
+		return
+	}
+
+	for f := target; len(f.Children) > 0; {
+		f = f.Children[len(f.Children)-1]
+		path = append(path, f)
+	}
+
+	common := 0
+	for common < len(path) && common < len(history.Frames) && path[common] == history.Frames[common] {
+		common++
+	}
+
+	affected := map[Undoable]bool{}
+	for _, frames := range [][]*UndoFrame{history.Frames[common:], path[common:]} {
+		for _, frame := range frames {
+			for object := range frame.States {
+				affected[object] = true
+			}
+		}
+	}
+
+	history.On = false
+	globals.EventLog.On = false
+
+	history.Frames = path
+	history.Index = targetIndex
+
+	for object := range affected {
+
+		if state := history.previousState(object); state != nil {
+			state.Apply()
+		} else {
+			object.UndoUncreate()
+		}
+
+	}
+
+	for _, page := range history.Project.Pages {
+		page.UpdateStacks = true
+	}
+
+	if len(target.States) > 0 {
+		history.Project.SetPage(target.UndoPage())
+	}
+
+	globals.EventLog.On = true
+	history.On = true
+
+	globals.EventLog.Log("Jumped to undo state: %s.", target.Label)
+
+	history.Project.Modified = true
+
+	history.Revision++
+
 }
 
 func (history *UndoHistory) Print() {
@@ -234,53 +659,429 @@ func (history *UndoHistory) Print() {
 
 func (history *UndoHistory) Clear() {
 	history.Frames = []*UndoFrame{}
+	history.Roots = []*UndoFrame{}
+	history.Base = NewUndoFrame()
+	history.Revision++
 	history.CurrentFrame = NewUndoFrame()
 	history.Changed = false
+	history.memoryUsage = 0
 }
 
 type UndoFrame struct {
-	States map[*Card]*UndoState
+	States   map[Undoable]*UndoState
+	Label    string
+	Time     time.Time
+	Parent   *UndoFrame
+	Children []*UndoFrame
 }
 
 func NewUndoFrame() *UndoFrame {
-	return &UndoFrame{States: map[*Card]*UndoState{}}
+	return &UndoFrame{States: map[Undoable]*UndoState{}}
+}
+
+// UndoPage returns the Page to switch to to see the frame's changes. Cards and links come first, as a Page's state may only
+// be its view, added along with them.
+func (frame *UndoFrame) UndoPage() *Page {
+
+	var page *Page
+
+	for object := range frame.States {
+		if _, isPage := object.(*Page); !isPage {
+			return object.UndoPage()
+		}
+		page = object.UndoPage()
+	}
+
+	return page
+
 }
 
+// Undoable is anything that can have a lane in the UndoHistory.
+type Undoable interface {
+	// UndoData returns the object's current state, serialized.
+	UndoData() string
+	// ApplyUndoData returns the object to the given serialized state; deletion indicates the state is of the object being deleted.
+	ApplyUndoData(data string, deletion bool)
+	// UndoUncreate is called when undoing past the first state in the object's lane (i.e. undoing its creation).
+	UndoUncreate()
+	// UndoPage returns the Page to switch to to see the object change.
+	UndoPage() *Page
+}
+
+// UndoKeyframeInterval is the most delta-compressed UndoStates that can follow each other in a lane before a full state is
+// stored again; it limits how much work it takes to rebuild a state.
+const UndoKeyframeInterval = 16
+
+// An UndoState either stores its object's serialized data in full, or as the difference from the previous UndoState in the
+// object's lane: the length of the prefix and suffix shared with that state, and the text that differs in between them. Edits (like
+// painting on a Map or typing into a Note) tend to only touch a small part of a Card's data, so this saves a lot of memory.
 type UndoState struct {
-	Card       *Card
-	Serialized string
-	Deletion   bool
+	Object   Undoable
+	Deletion bool
+
+	full   string
+	base   *UndoState
+	prefix int
+	suffix int
+	middle string
+	chain  int
 }
 
-func NewUndoState(card *Card) *UndoState {
+func NewUndoState(object Undoable) *UndoState {
 
 	state := &UndoState{
-		Card:       card,
-		Serialized: card.Serialize(),
+		Object: object,
+		full:   object.UndoData(),
 	}
 
 	return state
 
 }
 
+// Serialized returns the object's serialized data for the UndoState.
+func (undoState *UndoState) Serialized() string {
+
+	if undoState.base == nil {
+		return undoState.full
+	}
+
+	base := undoState.base.Serialized()
+
+	return base[:undoState.prefix] + undoState.middle + base[len(base)-undoState.suffix:]
+
+}
+
+// compress stores the UndoState as a difference from the previous state in its lane, if that's worth doing.
+func (undoState *UndoState) compress(base *UndoState) {
+
+	if undoState.base != nil || base.chain+1 >= UndoKeyframeInterval {
+		return
+	}
+
+	data := undoState.full
+	baseData := base.Serialized()
+
+	maxCommon := len(data)
+	if len(baseData) < maxCommon {
+		maxCommon = len(baseData)
+	}
+
+	prefix := 0
+	for prefix < maxCommon && data[prefix] == baseData[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < maxCommon-prefix && data[len(data)-1-suffix] == baseData[len(baseData)-1-suffix] {
+		suffix++
+	}
+
+	middle := data[prefix : len(data)-suffix]
+
+	// Small states aren't worth the trouble.
+	if len(middle)+64 >= len(data) {
+		return
+	}
+
+	undoState.full = ""
+	undoState.base = base
+	undoState.prefix = prefix
+	undoState.suffix = suffix
+	undoState.middle = middle
+	undoState.chain = base.chain + 1
+
+}
+
+// materialize stores the UndoState's data in full again, so it no longer depends on the state before it.
+func (undoState *UndoState) materialize() {
+
+	if undoState.base == nil {
+		return
+	}
+
+	undoState.full = undoState.Serialized()
+	undoState.base = nil
+	undoState.middle = ""
+	undoState.chain = 0
+
+}
+
+func (undoState *UndoState) size() int {
+	return len(undoState.full) + len(undoState.middle) + 64
+}
+
 func (undoState *UndoState) String() string {
-	return undoState.Serialized + fmt.Sprintf(" Deletion: %t", undoState.Deletion)
+	return undoState.Serialized() + fmt.Sprintf(" Deletion: %t", undoState.Deletion)
 }
 
 func (undoState *UndoState) SameAs(other *UndoState) bool {
-	return undoState.Serialized == other.Serialized && other.Deletion == undoState.Deletion
+	return other.Deletion == undoState.Deletion && undoState.Serialized() == other.Serialized()
 }
 
 func (undoState *UndoState) Apply() {
+	undoState.Object.ApplyUndoData(undoState.Serialized(), undoState.Deletion)
+}
 
-	undoState.Card.Deserialize(undoState.Serialized)
-	undoState.Card.ReceiveMessage(NewMessage(MessageUndoRedo, undoState.Card, nil))
-	undoState.Card.CreateUndoState = false
+// Serialize returns the UndoHistory as JSON to be saved alongside the project file, so it can be restored the next time the
+// project is opened. projectData is the project file's contents, which the history only applies to. At most frameCap frames
+// are kept; older frames are folded together into a single starting frame. Only the current branch is saved.
+func (history *UndoHistory) Serialize(projectData string, frameCap int) string {
 
-	if undoState.Deletion {
-		undoState.Card.Page.DeleteCards(undoState.Card)
-	} else if !undoState.Card.Valid {
-		undoState.Card.Page.RestoreCards(undoState.Card)
+	if frameCap < 1 {
+		frameCap = 1
 	}
 
+	start := len(history.Frames) - frameCap
+	if start > history.Index-1 {
+		start = history.Index - 1
+	}
+	if start < 0 {
+		start = 0
+	}
+
+	// The first saved frame needs the latest state of every object as of that point, as undoing looks back through previous
+	// frames to find the state to return an object to. It can't be undone, so it stands in for the Base frame as well.
+	base := NewUndoFrame()
+	for object, state := range history.Base.States {
+		base.States[object] = state
+	}
+	for i := 0; i <= start && i < len(history.Frames); i++ {
+		for object, state := range history.Frames[i].States {
+			base.States[object] = state
+		}
+	}
+
+	if start < len(history.Frames) {
+		base.Label = history.Frames[start].Label
+		base.Time = history.Frames[start].Time
+	}
+
+	frames := []*UndoFrame{}
+	if len(history.Frames) > 0 {
+		frames = append([]*UndoFrame{base}, history.Frames[start+1:]...)
+	}
+
+	data, _ := sjson.Set("{}", "version", globals.Version.String())
+	data, _ = sjson.Set(data, "project hash", projectHash(projectData))
+	data, _ = sjson.Set(data, "index", history.Index-start)
+	data, _ = sjson.SetRaw(data, "frames", "[]")
+
+	for _, frame := range frames {
+
+		frameData := `{"states": []}`
+		frameData, _ = sjson.Set(frameData, "label", frame.Label)
+		frameData, _ = sjson.Set(frameData, "time", frame.Time.Unix())
+
+		for object, state := range frame.States {
+
+			stateData := "{}"
+
+			switch obj := object.(type) {
+			case *Card:
+				stateData, _ = sjson.Set(stateData, "card", obj.ID)
+				stateData, _ = sjson.Set(stateData, "page", obj.Page.ID)
+			case *Page:
+				stateData, _ = sjson.Set(stateData, "kind", "page")
+				stateData, _ = sjson.Set(stateData, "page", obj.ID)
+			case *LinkEnding:
+				stateData, _ = sjson.Set(stateData, "kind", "link")
+				stateData, _ = sjson.Set(stateData, "card", obj.Start.ID)
+				stateData, _ = sjson.Set(stateData, "end", obj.End.ID)
+			default:
+				continue
+			}
+
+			stateData, _ = sjson.Set(stateData, "deletion", state.Deletion)
+			stateData, _ = sjson.Set(stateData, "data", state.Serialized())
+			frameData, _ = sjson.SetRaw(frameData, "states.-1", stateData)
+		}
+
+		data, _ = sjson.SetRaw(data, "frames.-1", frameData)
+
+	}
+
+	return data
+
+}
+
+// Deserialize restores the UndoHistory from data created by UndoHistory.Serialize(), returning false if it couldn't be (i.e. if the
+// project file's been changed since the history was saved). Cards that have been deleted are recreated (as deleted Cards) so their
+// deletion can be undone.
+func (history *UndoHistory) Deserialize(data string, projectData string) bool {
+
+	if gjson.Get(data, "project hash").String() != projectHash(projectData) {
+		return false
+	}
+
+	project := history.Project
+
+	cards := map[int64]*Card{}
+	for _, page := range project.Pages {
+		for _, card := range page.Cards {
+			cards[card.ID] = card
+		}
+	}
+
+	pages := map[uint64]*Page{}
+	for _, page := range project.Pages {
+		pages[page.ID] = page
+	}
+
+	links := map[[2]int64]*LinkEnding{}
+
+	history.On = false
+
+	frames := []*UndoFrame{}
+	lastStates := map[Undoable]*UndoState{}
+
+	for _, frameData := range gjson.Get(data, "frames").Array() {
+
+		frame := NewUndoFrame()
+		frame.Label = frameData.Get("label").String()
+		frame.Time = time.Unix(frameData.Get("time").Int(), 0)
+
+		if len(frames) > 0 {
+			frame.Parent = frames[len(frames)-1]
+			frame.Parent.Children = append(frame.Parent.Children, frame)
+		}
+
+		for _, stateData := range frameData.Get("states").Array() {
+
+			var object Undoable
+
+			id := stateData.Get("card").Int()
+
+			switch stateData.Get("kind").String() {
+
+			case "page":
+
+				page, exists := pages[stateData.Get("page").Uint()]
+				if !exists {
+					continue
+				}
+				object = page
+
+			case "link":
+
+				start, end := cards[id], cards[stateData.Get("end").Int()]
+				if start == nil || end == nil {
+					continue
+				}
+
+				key := [2]int64{start.ID, end.ID}
+
+				if _, exists := links[key]; !exists {
+
+					// The link doesn't need to exist right now for it to have a lane; applying a state changes whichever
+					// LinkEnding joins the Cards at the time.
+					links[key] = NewLinkEnding(start, end)
+					for _, link := range start.Links {
+						if link.Start == start && link.End == end {
+							links[key] = link
+						}
+					}
+
+				}
+
+				object = links[key]
+
+			default:
+
+				card, exists := cards[id]
+
+				if !exists {
+
+					page := pages[stateData.Get("page").Uint()]
+
+					if page == nil {
+						continue
+					}
+
+					card = page.CreateNewCard(ContentTypeCheckbox)
+					card.Deserialize(stateData.Get("data").String())
+					card.ID = id
+					if globalCardID <= id {
+						globalCardID = id + 1
+					}
+					card.CreateUndoState = false
+					page.DeleteCards(card)
+
+					// The Card's links only get recreated if its deletion is undone.
+					page.DeserializationLinks = []string{}
+
+					cards[id] = card
+
+				}
+
+				object = card
+
+			}
+
+			state := &UndoState{
+				Object:   object,
+				full:     stateData.Get("data").String(),
+				Deletion: stateData.Get("deletion").Bool(),
+			}
+
+			if prev, exists := lastStates[object]; exists {
+				state.compress(prev)
+			}
+
+			lastStates[object] = state
+			frame.States[object] = state
+
+		}
+
+		frames = append(frames, frame)
+
+	}
+
+	history.On = true
+
+	if len(frames) == 0 {
+		return false
+	}
+
+	history.Frames = frames
+	history.Roots = []*UndoFrame{frames[0]}
+	history.Base = NewUndoFrame()
+	history.CurrentFrame = NewUndoFrame()
+	history.Index = int(gjson.Get(data, "index").Int())
+	if history.Index > len(frames) {
+		history.Index = len(frames)
+	}
+	history.MinimumFrame = 1
+	history.Changed = false
+
+	history.countMemoryUsage()
+
+	return true
+
+}
+
+// Reset clears the UndoHistory, leaving a single frame with the current state of every Card that can't be undone past.
+func (history *UndoHistory) Reset() {
+
+	modified := history.Project.Modified
+
+	history.Clear()
+	history.Index = 0
+
+	for _, page := range history.Project.Pages {
+		for _, card := range page.Cards {
+			if card.Valid {
+				history.Capture(NewUndoState(card))
+			}
+		}
+	}
+
+	history.Update()
+	history.MinimumFrame = 1
+
+	history.Project.Modified = modified
+
+}
+
+func projectHash(projectData string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(projectData)))
 }